@@ -0,0 +1,35 @@
+package tetris
+
+import "fmt"
+
+// WithBigMode halves the field so every cell is drawn as 2x2 blocks. The
+// board width and height must be even, NewBoard panics otherwise.
+func WithBigMode() BoardOption {
+	return func(board *Board) {
+		board.big = true
+	}
+}
+
+func (b *Board) applyBigMode() {
+	if !b.big {
+		return
+	}
+	if b.width%2 != 0 || b.height%2 != 0 {
+		panic(fmt.Errorf("big mode needs an even width and height"))
+	}
+	b.width /= 2
+	b.height /= 2
+	b.hiddenRows = (b.hiddenRows + 1) / 2
+}
+
+func (b *Board) scaleFrame(frame [][]Tile) [][]Tile {
+	if !b.big {
+		return frame
+	}
+	for y := range b.bigFrame {
+		for x := range b.bigFrame[y] {
+			b.bigFrame[y][x] = frame[y/2][x/2]
+		}
+	}
+	return b.bigFrame
+}
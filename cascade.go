@@ -0,0 +1,99 @@
+package tetris
+
+func WithCascade() BoardOption {
+	return func(board *Board) {
+		board.cascade = true
+	}
+}
+
+func (b *Board) applyCascadeGravity() {
+	for {
+		chunks := b.tileChunks()
+		falling := make([]bool, len(chunks), len(chunks))
+		moved := false
+		for i, chunk := range chunks {
+			falling[i] = b.canChunkFall(chunk)
+			moved = moved || falling[i]
+		}
+		if !moved {
+			return
+		}
+
+		for i, chunk := range chunks {
+			if !falling[i] {
+				continue
+			}
+			for j := len(chunk) - 1; j >= 0; j-- {
+				y, x := chunk[j][0], chunk[j][1]
+				b.tiles[y+1][x], b.tiles[y][x] = b.tiles[y][x], TileEmpty
+				b.lockedAt[y+1][x] = b.lockedAt[y][x]
+			}
+		}
+	}
+}
+
+func (b *Board) tileChunks() [][][2]int {
+	chunkOf := make([][]int, b.height, b.height)
+	for y := range chunkOf {
+		chunkOf[y] = make([]int, b.width, b.width)
+		for x := range chunkOf[y] {
+			chunkOf[y][x] = -1
+		}
+	}
+
+	chunks := [][][2]int{}
+	for y := 0; y < b.height; y++ {
+		for x := 0; x < b.width; x++ {
+			if b.tiles[y][x] == TileEmpty || chunkOf[y][x] >= 0 {
+				continue
+			}
+
+			chunk := [][2]int{}
+			chunkOf[y][x] = len(chunks)
+			stack := [][2]int{{y, x}}
+			for len(stack) > 0 {
+				cell := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				chunk = append(chunk, cell)
+				for _, d := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+					ny, nx := cell[0]+d[0], cell[1]+d[1]
+					if ny < 0 || ny >= b.height || nx < 0 || nx >= b.width {
+						continue
+					}
+					if b.tiles[ny][nx] != TileEmpty && chunkOf[ny][nx] < 0 {
+						chunkOf[ny][nx] = len(chunks)
+						stack = append(stack, [2]int{ny, nx})
+					}
+				}
+			}
+			sortCellsByRow(chunk)
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks
+}
+
+func (b *Board) canChunkFall(chunk [][2]int) bool {
+	inChunk := make(map[[2]int]bool, len(chunk))
+	for _, cell := range chunk {
+		inChunk[cell] = true
+	}
+	for _, cell := range chunk {
+		y, x := cell[0]+1, cell[1]
+		if y >= b.height {
+			return false
+		}
+		if b.tiles[y][x] != TileEmpty && !inChunk[[2]int{y, x}] {
+			return false
+		}
+	}
+	return true
+}
+
+func sortCellsByRow(cells [][2]int) {
+	for i := 1; i < len(cells); i++ {
+		for j := i; j > 0 && cells[j][0] < cells[j-1][0]; j-- {
+			cells[j], cells[j-1] = cells[j-1], cells[j]
+		}
+	}
+}
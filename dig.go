@@ -0,0 +1,62 @@
+package tetris
+
+import "fmt"
+
+func WithDig(total, visible int) BoardOption {
+	if total < 0 || visible < 0 {
+		panic(fmt.Errorf("dig rows cannot be negative"))
+	}
+	return func(board *Board) {
+		board.digTotal = total
+		board.digRows = visible
+		if visible == 0 || visible > total {
+			board.digRows = total
+		}
+	}
+}
+
+func (b *Board) feedDigRows() {
+	rows := b.countGarbageRows()
+	for ; rows < b.digRows && b.digQueued > 0 && !b.isOver; rows++ {
+		b.raiseGround(b.cheeseHole())
+		b.digQueued--
+	}
+}
+
+func (b *Board) cheeseHole() int {
+	last, garbage := -1, false
+	for x, tile := range b.tiles[b.height-1] {
+		if tile == TileEmpty && last < 0 {
+			last = x
+		}
+		garbage = garbage || tile == TileAdditionalBlock
+	}
+	if !garbage || last < 0 || b.width < 2 {
+		return b.garbageRandomizer.Intn(b.width)
+	}
+	hole := b.garbageRandomizer.Intn(b.width - 1)
+	if hole >= last {
+		hole++
+	}
+	return hole
+}
+
+func (b *Board) countGarbageRows() int {
+	rows := 0
+	for y := 0; y < b.height; y++ {
+		for x := 0; x < b.width; x++ {
+			if b.tiles[y][x] == TileAdditionalBlock {
+				rows++
+				break
+			}
+		}
+	}
+	return rows
+}
+
+func (b *Board) digRemaining() int {
+	if b.digTotal == 0 {
+		return 0
+	}
+	return b.countGarbageRows() + b.digQueued
+}
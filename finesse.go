@@ -0,0 +1,80 @@
+package tetris
+
+type finesseState struct {
+	t        Tetromino
+	x        int
+	rotation int
+}
+
+func (b *Board) finesseFaults() int {
+	if b.spawned == (Tetromino{}) {
+		return 0
+	}
+	if minimal, ok := b.minimalInputs(); ok && b.inputs > minimal {
+		return b.inputs - minimal
+	}
+	return 0
+}
+
+func (b *Board) minimalInputs() (int, bool) {
+	target := normalizeTetromino(b.current)
+	_, targetLeft, _, _ := tetrominoBounds(b.current)
+	targetX := b.currentX + targetLeft
+
+	start := finesseState{t: b.spawned, x: b.spawnX}
+	distance := map[finesseState]int{start: 0}
+	queue := []finesseState{start}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		_, left, _, _ := tetrominoBounds(state.t)
+		if normalizeTetromino(state.t) == target && state.x+left == targetX {
+			return distance[state], true
+		}
+
+		for _, next := range b.finesseMoves(state) {
+			if _, ok := distance[next]; !ok {
+				distance[next] = distance[state] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	return 0, false
+}
+
+func (b *Board) finesseMoves(state finesseState) []finesseState {
+	moves := make([]finesseState, 0, 6)
+	for _, dx := range []int{-1, 1} {
+		if !b.fitsWidth(state.t, state.x+dx) {
+			continue
+		}
+		moves = append(moves, finesseState{t: state.t, x: state.x + dx, rotation: state.rotation})
+
+		wall := state.x + dx
+		for b.fitsWidth(state.t, wall+dx) {
+			wall += dx
+		}
+		moves = append(moves, finesseState{t: state.t, x: wall, rotation: state.rotation})
+	}
+
+	for _, clockwise := range []bool{false, true} {
+		rotated, kicks := b.rotation.Rotate(state.t, state.rotation, clockwise)
+		rotation := (state.rotation + 3) % 4
+		if clockwise {
+			rotation = (state.rotation + 1) % 4
+		}
+		for _, kick := range kicks {
+			if b.fitsWidth(rotated, state.x+kick[0]) {
+				moves = append(moves, finesseState{t: rotated, x: state.x + kick[0], rotation: rotation})
+				break
+			}
+		}
+	}
+	return moves
+}
+
+func (b *Board) fitsWidth(t Tetromino, x int) bool {
+	_, left, _, right := tetrominoBounds(t)
+	return x+left >= 0 && x+right < b.width
+}
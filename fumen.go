@@ -0,0 +1,469 @@
+package tetris
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	fumenPrefix       = "v115@"
+	fumenWidth        = 10
+	fumenTop          = 23
+	fumenBlocks       = (fumenTop + 1) * fumenWidth
+	fumenTable        = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	fumenCommentTable = " !\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~"
+	fumenGray         = 8
+)
+
+type FumenPage struct {
+	State   State
+	Comment string
+}
+
+type fumenField [fumenTop + 1][fumenWidth]int
+
+var fumenShapes = [][][2]int{
+	1: {{0, 0}, {-1, 0}, {1, 0}, {2, 0}},
+	2: {{0, 0}, {-1, 0}, {1, 0}, {1, 1}},
+	3: {{0, 0}, {1, 0}, {0, 1}, {1, 1}},
+	4: {{0, 0}, {1, 0}, {0, 1}, {-1, 1}},
+	5: {{0, 0}, {-1, 0}, {1, 0}, {0, 1}},
+	6: {{0, 0}, {-1, 0}, {1, 0}, {-1, 1}},
+	7: {{0, 0}, {-1, 0}, {0, 1}, {1, 1}},
+}
+
+var fumenTetrominoes = []Tetromino{1: TetrominoI, 2: TetrominoL, 3: TetrominoO, 4: TetrominoZ, 5: TetrominoT, 6: TetrominoJ, 7: TetrominoS}
+
+var fumenRotations = []int{2, 1, 0, 3}
+
+var fumenOffsets = map[[2]int][2]int{
+	{3, 3}: {1, -1},
+	{3, 2}: {1, 0},
+	{3, 0}: {0, -1},
+	{1, 2}: {1, 0},
+	{1, 3}: {0, -1},
+	{7, 0}: {0, -1},
+	{7, 1}: {-1, 0},
+	{4, 0}: {0, -1},
+	{4, 3}: {1, 0},
+}
+
+func fumenCells(piece, rotation, x, y int) [][2]int {
+	cells := make([][2]int, 0, 4)
+	for _, cell := range fumenShapes[piece] {
+		dx, dy := cell[0], cell[1]
+		switch rotation {
+		case 1:
+			dx, dy = dy, -dx
+		case 2:
+			dx, dy = -dx, -dy
+		case 3:
+			dx, dy = -dy, dx
+		}
+		cells = append(cells, [2]int{x + dx, y + dy})
+	}
+	return cells
+}
+
+func (f *fumenField) set(x, y, value int) bool {
+	if x < 0 || x >= fumenWidth || y < -1 || y >= fumenTop {
+		return false
+	}
+	f[fumenTop-1-y][x] = value
+	return true
+}
+
+func (f fumenField) lock(piece, rotation, x, y int, rise, mirror bool) fumenField {
+	if piece >= 1 && piece <= 7 {
+		for _, cell := range fumenCells(piece, rotation, x, y) {
+			f.set(cell[0], cell[1], piece)
+		}
+	}
+
+	rows := fumenField{}
+	bottom := fumenTop - 1
+	for r := fumenTop - 1; r >= 0; r-- {
+		full := true
+		for _, value := range f[r] {
+			full = full && value != 0
+		}
+		if !full {
+			rows[bottom] = f[r]
+			bottom--
+		}
+	}
+	rows[fumenTop] = f[fumenTop]
+	f = rows
+
+	if rise {
+		for r := 0; r < fumenTop; r++ {
+			f[r] = f[r+1]
+		}
+		f[fumenTop] = [fumenWidth]int{}
+	}
+	if mirror {
+		for r := 0; r < fumenTop; r++ {
+			for x := 0; x < fumenWidth/2; x++ {
+				f[r][x], f[r][fumenWidth-1-x] = f[r][fumenWidth-1-x], f[r][x]
+			}
+		}
+	}
+	return f
+}
+
+type fumenReader struct {
+	values []int
+	pos    int
+}
+
+func (r *fumenReader) poll(n int) (int, error) {
+	if r.pos+n > len(r.values) {
+		return 0, fmt.Errorf("fumen data ends unexpectedly")
+	}
+	value := 0
+	for i := n - 1; i >= 0; i-- {
+		value = value*64 + r.values[r.pos+i]
+	}
+	r.pos += n
+	return value, nil
+}
+
+func DecodeFumen(data string, height int) ([]FumenPage, error) {
+	i := strings.Index(data, fumenPrefix)
+	if i < 0 {
+		return nil, fmt.Errorf("unsupported fumen data, expected %s", fumenPrefix)
+	}
+	data = strings.Replace(data[i+len(fumenPrefix):], "?", "", -1)
+
+	r := &fumenReader{values: make([]int, len(data), len(data))}
+	for i, ch := range []byte(data) {
+		value := strings.IndexByte(fumenTable, ch)
+		if value < 0 {
+			return nil, fmt.Errorf("invalid fumen character %q", ch)
+		}
+		r.values[i] = value
+	}
+
+	pages := make([]FumenPage, 0, 0)
+	field, comment, repeat := fumenField{}, "", 0
+	for r.pos < len(r.values) {
+		if repeat > 0 {
+			repeat--
+		} else {
+			changed := false
+			for index := 0; index < fumenBlocks; {
+				run, err := r.poll(2)
+				if err != nil {
+					return nil, err
+				}
+				diff, count := run/fumenBlocks-8, run%fumenBlocks+1
+				changed = changed || diff != 0 || count != fumenBlocks
+				for ; count > 0 && index < fumenBlocks; count-- {
+					field[index/fumenWidth][index%fumenWidth] += diff
+					index++
+				}
+			}
+			if !changed {
+				var err error
+				if repeat, err = r.poll(1); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		action, err := r.poll(3)
+		if err != nil {
+			return nil, err
+		}
+		piece, rotation := action%8, fumenRotations[action/8%4]
+		location, flags := action/32%fumenBlocks, action/32/fumenBlocks
+		x, y := location%fumenWidth, fumenTop-location/fumenWidth-1
+		if offset, ok := fumenOffsets[[2]int{piece, rotation}]; ok {
+			x, y = x+offset[0], y+offset[1]
+		}
+
+		if flags&8 != 0 {
+			if comment, err = decodeFumenComment(r); err != nil {
+				return nil, err
+			}
+		}
+
+		state, err := fumenState(field, piece, rotation, x, y, height)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %v", len(pages)+1, err)
+		}
+		pages = append(pages, FumenPage{State: state, Comment: comment})
+
+		if flags&16 == 0 {
+			field = field.lock(piece, rotation, x, y, flags&1 != 0, flags&2 != 0)
+		}
+	}
+	return pages, nil
+}
+
+func fumenState(field fumenField, piece, rotation, x, y, height int) (State, error) {
+	state := State{Tiles: make([][]Tile, height, height)}
+	for row := range state.Tiles {
+		state.Tiles[row] = make([]Tile, fumenWidth, fumenWidth)
+	}
+	for r := 0; r < fumenTop; r++ {
+		for col, value := range field[r] {
+			if value == 0 {
+				continue
+			}
+			row := height - fumenTop + r
+			if row < 0 {
+				return State{}, fmt.Errorf("field does not fit in %d rows", height)
+			}
+			state.Tiles[row][col] = TileNormalBlock
+		}
+	}
+
+	if piece < 1 || piece > 7 {
+		return state, nil
+	}
+	cells := fumenCells(piece, rotation, x, y)
+	minX, maxY := fumenWidth, -1
+	for _, cell := range cells {
+		minX, maxY = minInt(minX, cell[0]), maxInt(maxY, cell[1])
+	}
+	for i, cell := range cells {
+		cells[i] = [2]int{maxY - cell[1], cell[0] - minX}
+	}
+	state.Current = newTetrominoFromCells(4, cells)
+	state.CurrentX, state.CurrentY = minX, height-1-maxY
+	spawn := GuidelineSpawnRules.orient(fumenTetrominoes[piece])
+	for i := 0; i < 4; i++ {
+		state.Rotation = (rotation + i) % 4
+		if normalizeTetromino(rotateTetrominoTimes(spawn, -state.Rotation)) == state.Current {
+			break
+		}
+	}
+	return state, nil
+}
+
+func EncodeFumen(pages []FumenPage) (string, error) {
+	if len(pages) == 0 {
+		return "", fmt.Errorf("fumen needs at least one page")
+	}
+
+	values := make([]int, 0, 0)
+	prev, comment, repeatAt := fumenField{}, "", -1
+	for i, page := range pages {
+		field, err := fumenFieldFromTiles(page.State.Tiles)
+		if err != nil {
+			return "", fmt.Errorf("page %d: %v", i+1, err)
+		}
+		if field != prev {
+			values = appendFumenField(values, prev, field)
+			repeatAt = -1
+		} else if repeatAt < 0 || values[repeatAt] == 63 {
+			values = appendFumenField(values, prev, field)
+			values = append(values, 0)
+			repeatAt = len(values) - 1
+		} else {
+			values[repeatAt]++
+		}
+
+		piece, rotation, x, y, err := fumenPiece(page.State)
+		if err != nil {
+			return "", fmt.Errorf("page %d: %v", i+1, err)
+		}
+		rawX, rawY := x, y
+		if offset, ok := fumenOffsets[[2]int{piece, rotation}]; ok {
+			rawX, rawY = x-offset[0], y-offset[1]
+		}
+		rawRotation := 0
+		for j, r := range fumenRotations {
+			if r == rotation {
+				rawRotation = j
+			}
+		}
+
+		flags := 0
+		if i == 0 {
+			flags |= 4
+		}
+		if page.Comment != comment {
+			flags |= 8
+		}
+		action := piece + 8*rawRotation + 32*((fumenTop-rawY-1)*fumenWidth+rawX) + 32*fumenBlocks*flags
+		values = appendFumenNumber(values, action, 3)
+		if page.Comment != comment {
+			values = appendFumenComment(values, page.Comment)
+			comment = page.Comment
+		}
+		prev = field.lock(piece, rotation, x, y, false, false)
+	}
+
+	data := make([]byte, len(values), len(values))
+	for i, value := range values {
+		data[i] = fumenTable[value]
+	}
+	if len(data) <= 42 {
+		return fumenPrefix + string(data), nil
+	}
+	chunks := []string{string(data[:42])}
+	for i := 42; i < len(data); i += 47 {
+		chunks = append(chunks, string(data[i:minInt(i+47, len(data))]))
+	}
+	return fumenPrefix + strings.Join(chunks, "?"), nil
+}
+
+func fumenFieldFromTiles(tiles [][]Tile) (fumenField, error) {
+	field := fumenField{}
+	for row := range tiles {
+		if len(tiles[row]) != fumenWidth {
+			return field, fmt.Errorf("fumen fields are %d columns wide", fumenWidth)
+		}
+		for x, tile := range tiles[row] {
+			if tile == TileEmpty || tile == TileTetromino {
+				continue
+			}
+			field.set(x, len(tiles)-1-row, fumenGray)
+		}
+	}
+	return field, nil
+}
+
+func fumenPiece(state State) (piece, rotation, x, y int, err error) {
+	if state.Current == (Tetromino{}) {
+		return 0, 2, 0, fumenTop - 1, nil
+	}
+
+	height := len(state.Tiles)
+	target := normalizeTetromino(state.Current)
+	for piece := 1; piece <= 7; piece++ {
+		for i := 0; i < 4; i++ {
+			rotation := (state.Rotation%4 + 4 + i) % 4
+			shape := fumenCells(piece, rotation, 0, 0)
+			minX, maxY := 4, -4
+			for _, cell := range shape {
+				minX, maxY = minInt(minX, cell[0]), maxInt(maxY, cell[1])
+			}
+			normalized := make([][2]int, len(shape), len(shape))
+			for i, cell := range shape {
+				normalized[i] = [2]int{maxY - cell[1], cell[0] - minX}
+			}
+			if state.Current.Size < 4 || newTetrominoFromCells(state.Current.Size, normalized) != target {
+				continue
+			}
+
+			top, left, _, _ := tetrominoBounds(state.Current)
+			x := state.CurrentX + left - minX
+			y := height - 1 - (state.CurrentY + top) - maxY
+			for _, cell := range fumenCells(piece, rotation, x, y) {
+				if cell[0] < 0 || cell[0] >= fumenWidth || cell[1] < 0 || cell[1] >= fumenTop {
+					return 0, 2, 0, fumenTop - 1, nil
+				}
+			}
+			return piece, rotation, x, y, nil
+		}
+	}
+	return 0, 0, 0, 0, fmt.Errorf("piece %v is not a tetromino", state.Current)
+}
+
+func appendFumenNumber(values []int, value, n int) []int {
+	for i := 0; i < n; i++ {
+		values = append(values, value%64)
+		value /= 64
+	}
+	return values
+}
+
+func appendFumenField(values []int, prev, field fumenField) []int {
+	runDiff, runCount := -1, 0
+	for index := 0; index < fumenBlocks; index++ {
+		r, x := index/fumenWidth, index%fumenWidth
+		diff := field[r][x] - prev[r][x] + 8
+		if diff != runDiff && runCount > 0 {
+			values = appendFumenNumber(values, runDiff*fumenBlocks+runCount-1, 2)
+			runCount = 0
+		}
+		runDiff = diff
+		runCount++
+	}
+	return appendFumenNumber(values, runDiff*fumenBlocks+runCount-1, 2)
+}
+
+func appendFumenComment(values []int, comment string) []int {
+	escaped := fumenEscape(comment)
+	if len(escaped) > 4095 {
+		escaped = escaped[:4095]
+	}
+	values = appendFumenNumber(values, len(escaped), 2)
+	for i := 0; i < len(escaped); i += 4 {
+		value, scale := 0, 1
+		for j := i; j < i+4 && j < len(escaped); j++ {
+			value += strings.IndexByte(fumenCommentTable, escaped[j]) * scale
+			scale *= len(fumenCommentTable) + 1
+		}
+		values = appendFumenNumber(values, value, 5)
+	}
+	return values
+}
+
+func decodeFumenComment(r *fumenReader) (string, error) {
+	length, err := r.poll(2)
+	if err != nil {
+		return "", err
+	}
+	escaped := make([]byte, 0, length+3)
+	for len(escaped) < length {
+		value, err := r.poll(5)
+		if err != nil {
+			return "", err
+		}
+		for i := 0; i < 4; i++ {
+			index := value % (len(fumenCommentTable) + 1)
+			if index >= len(fumenCommentTable) {
+				return "", fmt.Errorf("invalid fumen comment")
+			}
+			escaped = append(escaped, fumenCommentTable[index])
+			value /= len(fumenCommentTable) + 1
+		}
+	}
+	return fumenUnescape(string(escaped[:length])), nil
+}
+
+func fumenEscape(s string) string {
+	var b strings.Builder
+	for _, ch := range s {
+		switch {
+		case ch < 128 && (ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || strings.ContainsRune("@*_+-./", ch)):
+			b.WriteRune(ch)
+		case ch < 256:
+			fmt.Fprintf(&b, "%%%02X", ch)
+		case ch < 0x10000:
+			fmt.Fprintf(&b, "%%u%04X", ch)
+		default:
+			ch -= 0x10000
+			fmt.Fprintf(&b, "%%u%04X%%u%04X", 0xD800+(ch>>10), 0xDC00+(ch&0x3FF))
+		}
+	}
+	return b.String()
+}
+
+func fumenUnescape(s string) string {
+	units := make([]uint16, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		var code uint64
+		if s[i] == '%' && i+5 < len(s) && s[i+1] == 'u' {
+			if _, err := fmt.Sscanf(s[i+2:i+6], "%04X", &code); err == nil {
+				units = append(units, uint16(code))
+				i += 5
+				continue
+			}
+		}
+		if s[i] == '%' && i+2 < len(s) {
+			if _, err := fmt.Sscanf(s[i+1:i+3], "%02X", &code); err == nil {
+				units = append(units, uint16(code))
+				i += 2
+				continue
+			}
+		}
+		units = append(units, uint16(s[i]))
+	}
+	return string(utf16.Decode(units))
+}
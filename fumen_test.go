@@ -0,0 +1,296 @@
+package tetris
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func fumenTestTiles(garbage ...string) [][]Tile {
+	tiles := make([][]Tile, fumenTop, fumenTop)
+	for y := range tiles {
+		tiles[y] = make([]Tile, fumenWidth, fumenWidth)
+	}
+	for i, row := range garbage {
+		for x, ch := range row {
+			if ch == '#' {
+				tiles[fumenTop-len(garbage)+i][x] = TileNormalBlock
+			}
+		}
+	}
+	return tiles
+}
+
+func fumenTestCells(state State) [][2]int {
+	cells := tetrominoCells(state.Current)
+	for i, cell := range cells {
+		cells[i] = [2]int{state.CurrentY + cell[0], state.CurrentX + cell[1]}
+	}
+	return cells
+}
+
+func fumenTestRoundTrip(t *testing.T, pages []FumenPage) []FumenPage {
+	t.Helper()
+	data, err := EncodeFumen(pages)
+	if err != nil {
+		t.Fatalf("cannot encode: %v", err)
+	}
+	decoded, err := DecodeFumen(data, fumenTop)
+	if err != nil {
+		t.Fatalf("cannot decode %s: %v", data, err)
+	}
+	if len(decoded) != len(pages) {
+		t.Fatalf("decoded %d pages from %s, want %d", len(decoded), data, len(pages))
+	}
+	return decoded
+}
+
+func TestDecodeFumenEmpty(t *testing.T) {
+	pages, err := DecodeFumen("v115@vhAAgH", fumenTop)
+	if err != nil {
+		t.Fatalf("cannot decode: %v", err)
+	}
+	if len(pages) != 1 {
+		t.Fatalf("decoded %d pages, want 1", len(pages))
+	}
+	if !reflect.DeepEqual(pages[0].State.Tiles, fumenTestTiles()) {
+		t.Errorf("field is not empty: %v", pages[0].State.Tiles)
+	}
+	if pages[0].State.Current != (Tetromino{}) {
+		t.Errorf("unexpected piece %v", pages[0].State.Current)
+	}
+}
+
+func fumenTestBoard(piece Tetromino, rotation int) *Board {
+	getter := NewQueueGetter()
+	getter.Push(piece, piece)
+	board := NewBoard(WithSize(fumenWidth, fumenTop), WithGetter(getter))
+	for i := 0; i < 5; i++ {
+		board.Apply(ActionTick)
+	}
+	for i := 0; i < rotation; i++ {
+		board.Apply(ActionRotateClockwise)
+	}
+	return board
+}
+
+func fumenTestPlace(board *Board, page State) {
+	state := board.GetState()
+	state.Tiles, state.Current = page.Tiles, page.Current
+	state.CurrentX, state.CurrentY, state.Rotation = page.CurrentX, page.CurrentY, page.Rotation
+	board.SetState(state)
+}
+
+func TestDecodeFumenPieces(t *testing.T) {
+	tests := []struct {
+		data     string
+		piece    Tetromino
+		rotation int
+	}{
+		{"v115@vhAR7H", TetrominoI, 0},
+		{"v115@vhAp7H", TetrominoI, 1},
+		{"v115@vhABAI", TetrominoI, 2},
+		{"v115@vhAZ7H", TetrominoI, 3},
+		{"v115@vhAT7H", TetrominoO, 0},
+		{"v115@vhAL7H", TetrominoO, 1},
+		{"v115@vhAD7H", TetrominoO, 2},
+		{"v115@vhAb7H", TetrominoO, 3},
+		{"v115@vhAX7H", TetrominoS, 0},
+		{"v115@vhAvAI", TetrominoS, 1},
+		{"v115@vhAHAI", TetrominoS, 2},
+		{"v115@vhAfAI", TetrominoS, 3},
+		{"v115@vhAU7H", TetrominoZ, 0},
+		{"v115@vhAMAI", TetrominoZ, 1},
+		{"v115@vhAEAI", TetrominoZ, 2},
+		{"v115@vhA8/H", TetrominoZ, 3},
+		{"v115@vhAVAI", TetrominoT, 0},
+		{"v115@vhANAI", TetrominoT, 1},
+		{"v115@vhAFAI", TetrominoT, 2},
+		{"v115@vhAdAI", TetrominoT, 3},
+		{"v115@vhASAI", TetrominoL, 0},
+		{"v115@vhAKAI", TetrominoL, 1},
+		{"v115@vhACAI", TetrominoL, 2},
+		{"v115@vhAaAI", TetrominoL, 3},
+		{"v115@vhAWAI", TetrominoJ, 0},
+		{"v115@vhAOAI", TetrominoJ, 1},
+		{"v115@vhAGAI", TetrominoJ, 2},
+		{"v115@vhAeAI", TetrominoJ, 3},
+	}
+
+	for _, test := range tests {
+		pages, err := DecodeFumen(test.data, fumenTop)
+		if err != nil {
+			t.Errorf("%s: cannot decode: %v", test.data, err)
+			continue
+		}
+		want := fumenTestBoard(test.piece, test.rotation)
+		page := pages[0].State
+		if got, want := fumenTestCells(page), fumenTestCells(want.GetState()); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: piece at %v, want %v", test.data, got, want)
+		}
+		if page.Rotation != test.rotation {
+			t.Errorf("%s: rotation %d, want %d", test.data, page.Rotation, test.rotation)
+		}
+
+		board := fumenTestBoard(test.piece, 0)
+		fumenTestPlace(board, page)
+		board.Apply(ActionRotateClockwise)
+		want.Apply(ActionRotateClockwise)
+		if got, want := board.GetState(), want.GetState(); !reflect.DeepEqual(fumenTestCells(got), fumenTestCells(want)) || got.Rotation != want.Rotation {
+			t.Errorf("%s: rotated to %v in state %d, want %v in state %d", test.data, fumenTestCells(got), got.Rotation, fumenTestCells(want), want.Rotation)
+		}
+	}
+}
+
+func TestDecodeFumenRotateOnFloor(t *testing.T) {
+	pages, err := DecodeFumen("v115@vhAVQJ", fumenTop)
+	if err != nil {
+		t.Fatalf("cannot decode: %v", err)
+	}
+	board := fumenTestBoard(TetrominoT, 0)
+	fumenTestPlace(board, pages[0].State)
+	if got, want := fumenTestCells(board.GetState()), [][2]int{{21, 4}, {22, 3}, {22, 4}, {22, 5}}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("piece at %v, want %v", got, want)
+	}
+
+	want := fumenTestBoard(TetrominoT, 0)
+	for i := 0; i < fumenTop; i++ {
+		want.Apply(ActionTick)
+	}
+	if got, want := fumenTestCells(board.GetState()), fumenTestCells(want.GetState()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("piece at %v, a dropped T is at %v", got, want)
+	}
+
+	board.Apply(ActionRotateClockwise)
+	want.Apply(ActionRotateClockwise)
+	if got, want := board.GetState(), want.GetState(); !reflect.DeepEqual(fumenTestCells(got), fumenTestCells(want)) || got.Rotation != want.Rotation {
+		t.Errorf("rotated to %v in state %d, want %v in state %d", fumenTestCells(got), got.Rotation, fumenTestCells(want), want.Rotation)
+	}
+}
+
+func TestFumenRoundTripPieces(t *testing.T) {
+	pages := []FumenPage{}
+	for _, tetromino := range fumenTetrominoes[1:] {
+		for rotation := 0; rotation < 4; rotation++ {
+			pages = append(pages, FumenPage{State: State{
+				Tiles:    fumenTestTiles("##.#######", "#.########"),
+				Current:  rotateTetrominoTimes(tetromino, rotation),
+				CurrentX: 3,
+				CurrentY: 10,
+			}})
+		}
+	}
+
+	decoded := fumenTestRoundTrip(t, pages)
+	for i := range pages {
+		if !reflect.DeepEqual(decoded[i].State.Tiles, pages[i].State.Tiles) {
+			t.Errorf("page %d: field %v, want %v", i+1, decoded[i].State.Tiles, pages[i].State.Tiles)
+		}
+		if got, want := fumenTestCells(decoded[i].State), fumenTestCells(pages[i].State); !reflect.DeepEqual(got, want) {
+			t.Errorf("page %d: piece at %v, want %v", i+1, got, want)
+		}
+	}
+}
+
+func TestFumenRoundTripComments(t *testing.T) {
+	comments := []string{"", "hello", "hello", "T-spin double!", "100% ok?", "テトリス", "🙂 emoji", ""}
+	pages := make([]FumenPage, len(comments), len(comments))
+	for i, comment := range comments {
+		pages[i] = FumenPage{State: State{Tiles: fumenTestTiles()}, Comment: comment}
+	}
+
+	decoded := fumenTestRoundTrip(t, pages)
+	for i := range pages {
+		if decoded[i].Comment != comments[i] {
+			t.Errorf("page %d: comment %q, want %q", i+1, decoded[i].Comment, comments[i])
+		}
+	}
+}
+
+func TestFumenRoundTripRepeatedPages(t *testing.T) {
+	pages := make([]FumenPage, 150, 150)
+	for i := range pages {
+		pages[i] = FumenPage{State: State{Tiles: fumenTestTiles("#########.")}}
+	}
+
+	decoded := fumenTestRoundTrip(t, pages)
+	for i := range pages {
+		if !reflect.DeepEqual(decoded[i].State.Tiles, pages[i].State.Tiles) {
+			t.Errorf("page %d: field %v, want %v", i+1, decoded[i].State.Tiles, pages[i].State.Tiles)
+		}
+	}
+}
+
+func TestEncodeFumenChunks(t *testing.T) {
+	lengths := map[int]bool{}
+	for count := 1; count <= 40; count++ {
+		for size := 0; size <= 24; size++ {
+			pages := make([]FumenPage, count, count)
+			for i := range pages {
+				pages[i] = FumenPage{State: State{Tiles: fumenTestTiles()}}
+			}
+			pages[0].Comment = strings.Repeat("a", size)
+
+			data, err := EncodeFumen(pages)
+			if err != nil {
+				t.Fatalf("cannot encode: %v", err)
+			}
+			chunks := strings.Split(strings.TrimPrefix(data, fumenPrefix), "?")
+			length := 0
+			for i, chunk := range chunks {
+				length += len(chunk)
+				switch {
+				case i == 0 && len(chunks) > 1 && len(chunk) != 42:
+					t.Errorf("%s: first chunk has %d characters, want 42", data, len(chunk))
+				case i > 0 && i < len(chunks)-1 && len(chunk) != 47:
+					t.Errorf("%s: chunk %d has %d characters, want 47", data, i, len(chunk))
+				case len(chunk) == 0:
+					t.Errorf("%s: empty chunk %d", data, i)
+				}
+			}
+			lengths[length] = true
+
+			decoded, err := DecodeFumen(data, fumenTop)
+			if err != nil {
+				t.Fatalf("cannot decode %s: %v", data, err)
+			}
+			if len(decoded) != count || decoded[0].Comment != pages[0].Comment {
+				t.Errorf("%s: decoded %d pages with comment %q", data, len(decoded), decoded[0].Comment)
+			}
+		}
+	}
+
+	for _, length := range []int{41, 42, 43, 89, 90} {
+		if !lengths[length] {
+			t.Errorf("no fumen with %d data characters was encoded", length)
+		}
+	}
+}
+
+func TestEncodeFumenTallBoard(t *testing.T) {
+	board := NewBoard(WithSize(10, 24), WithHiddenRows(2))
+	state := board.GetState()
+	state.Tiles[0][0] = TileNormalBlock
+	state.Tiles[len(state.Tiles)-1][0] = TileNormalBlock
+
+	data, err := EncodeFumen([]FumenPage{{State: state}})
+	if err != nil {
+		t.Fatalf("cannot encode a freshly spawned board: %v", err)
+	}
+	pages, err := DecodeFumen(data, len(state.Tiles))
+	if err != nil {
+		t.Fatalf("cannot decode %s: %v", data, err)
+	}
+
+	want := make([][]Tile, len(state.Tiles), len(state.Tiles))
+	for y := range want {
+		want[y] = make([]Tile, fumenWidth, fumenWidth)
+	}
+	want[len(want)-1][0] = TileNormalBlock
+	if !reflect.DeepEqual(pages[0].State.Tiles, want) {
+		t.Errorf("got field %v, want only the bottom left block", pages[0].State.Tiles)
+	}
+	if pages[0].State.Current != (Tetromino{}) {
+		t.Errorf("got piece %v above the fumen field, want none", pages[0].State.Current)
+	}
+}
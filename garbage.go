@@ -0,0 +1,159 @@
+package tetris
+
+import "time"
+
+type AttackTable struct {
+	Lines        [5]int
+	TSpin        [4]int
+	Combo        []int
+	BackToBack   int
+	PerfectClear int
+}
+
+var GuidelineAttackTable = AttackTable{
+	Lines:        [5]int{0, 0, 1, 2, 4},
+	TSpin:        [4]int{0, 2, 4, 6},
+	Combo:        []int{0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 4, 5},
+	BackToBack:   1,
+	PerfectClear: 10,
+}
+
+func (t AttackTable) attack(rows int, tSpin bool, combo int, backToBack, perfectClear bool) int {
+	attack := 0
+	if tSpin {
+		attack = t.TSpin[minInt(rows, len(t.TSpin)-1)]
+	} else {
+		attack = t.Lines[minInt(rows, len(t.Lines)-1)]
+	}
+
+	if len(t.Combo) > 0 {
+		attack += t.Combo[minInt(combo, len(t.Combo)-1)]
+	}
+
+	if backToBack {
+		attack += t.BackToBack
+	}
+
+	if perfectClear {
+		attack += t.PerfectClear
+	}
+
+	return attack
+}
+
+type pendingGarbage struct {
+	lines   int
+	readyAt time.Time
+}
+
+func WithAttackTable(table AttackTable) BoardOption {
+	return func(board *Board) {
+		board.attackTable = table
+	}
+}
+
+func WithGarbageDelay(delay time.Duration) BoardOption {
+	return func(board *Board) {
+		board.garbageDelay = delay
+	}
+}
+
+func (b *Board) ReceiveGarbage(lines int) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if lines <= 0 || b.isOver {
+		return
+	}
+	b.pendingGarbage = append(b.pendingGarbage, pendingGarbage{
+		lines:   lines,
+		readyAt: time.Now().Add(b.garbageDelay),
+	})
+}
+
+func (b *Board) countPendingGarbage() int {
+	lines := 0
+	for _, garbage := range b.pendingGarbage {
+		lines += garbage.lines
+	}
+	return lines
+}
+
+func (b *Board) cancelPendingGarbage(attack int) int {
+	for attack > 0 && len(b.pendingGarbage) > 0 {
+		if b.pendingGarbage[0].lines > attack {
+			b.pendingGarbage[0].lines -= attack
+			return 0
+		}
+		attack -= b.pendingGarbage[0].lines
+		b.pendingGarbage = b.pendingGarbage[1:]
+	}
+	return attack
+}
+
+func (b *Board) insertPendingGarbage() {
+	now := time.Now()
+	for len(b.pendingGarbage) > 0 && !b.pendingGarbage[0].readyAt.After(now) && !b.isOver {
+		for _, hole := range b.garbageHoles(b.pendingGarbage[0].lines) {
+			b.raiseGround(hole)
+		}
+		b.pendingGarbage = b.pendingGarbage[1:]
+	}
+}
+
+func (b *Board) applyFill() {
+	b.addGarbage(1)
+}
+
+func (b *Board) addGarbage(lines int) {
+	for _, hole := range b.garbageHoles(lines) {
+		if b.isOver {
+			return
+		}
+
+		b.raiseGround(hole)
+		if b.isOverlapGround() {
+			b.currentY--
+		}
+		if b.isOverlapGround() {
+			b.isOver = true
+		}
+	}
+}
+
+func (b *Board) garbageHoles(lines int) []int {
+	if lines <= 0 {
+		return nil
+	}
+
+	holes := make([]int, lines, lines)
+	holes[0] = b.garbageRandomizer.Intn(b.width)
+	for i := 1; i < lines; i++ {
+		holes[i] = holes[i-1]
+		if b.garbageRandomizer.Float64() < b.garbageMessiness {
+			holes[i] = b.garbageRandomizer.Intn(b.width)
+		}
+	}
+	return holes
+}
+
+func (b *Board) raiseGround(hole int) {
+	for x := 0; x < b.width && b.topOut.GarbageTopOut; x++ {
+		if b.tiles[0][x] != TileEmpty {
+			b.isOver = true
+			return
+		}
+	}
+
+	for y := 0; y < b.height-1; y++ {
+		for x := 0; x < b.width; x++ {
+			b.tiles[y][x] = b.tiles[y+1][x]
+			b.lockedAt[y][x] = b.lockedAt[y+1][x]
+		}
+	}
+	for x := 0; x < b.width; x++ {
+		b.tiles[b.height-1][x] = TileAdditionalBlock
+		b.lockedAt[b.height-1][x] = time.Now()
+	}
+	b.tiles[b.height-1][hole] = TileEmpty
+}
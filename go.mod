@@ -3,8 +3,9 @@ module github.com/jauhararifin/tetris
 go 1.14
 
 require (
+	github.com/BurntSushi/toml v0.3.0
 	github.com/JoelOtter/termloop v0.0.0-20200419101407-3d3210f46446
-	github.com/google/uuid v1.1.1 // indirect
+	github.com/google/uuid v1.1.1
 	github.com/mattn/go-runewidth v0.0.9 // indirect
 	github.com/nsf/termbox-go v0.0.0-20200418040025-38ba6e5628f1 // indirect
 )
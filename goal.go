@@ -0,0 +1,46 @@
+package tetris
+
+import (
+	"fmt"
+	"time"
+)
+
+func WithLineGoal(lines int) BoardOption {
+	if lines < 0 {
+		panic(fmt.Errorf("line goal cannot be negative"))
+	}
+	return func(board *Board) {
+		board.lineGoal = lines
+	}
+}
+
+func WithTimeLimit(limit time.Duration) BoardOption {
+	if limit < 0 {
+		panic(fmt.Errorf("time limit cannot be negative"))
+	}
+	return func(board *Board) {
+		board.timeLimit = limit
+	}
+}
+
+func (b *Board) playTime() time.Duration {
+	elapsed := b.clockElapsed
+	if !b.clockStart.IsZero() {
+		elapsed += time.Since(b.clockStart)
+	}
+	if b.timeLimit > 0 && elapsed > b.timeLimit {
+		return b.timeLimit
+	}
+	return elapsed
+}
+
+func (b *Board) updateClock() {
+	if !b.isOver && b.timeLimit > 0 && b.playTime() >= b.timeLimit {
+		b.isOver = true
+		b.isCompleted = true
+	}
+	if b.isOver && !b.clockStart.IsZero() {
+		b.clockElapsed = b.playTime()
+		b.clockStart = time.Time{}
+	}
+}
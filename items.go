@@ -0,0 +1,146 @@
+package tetris
+
+import (
+	"fmt"
+	"time"
+)
+
+type Item int
+
+const (
+	ItemClearRows Item = iota
+	ItemFlip
+	ItemSpeedUp
+	ItemHidePreview
+)
+
+var itemNames = []string{"Clear rows", "Flip", "Speed up", "Hide preview"}
+
+func (i Item) String() string {
+	if i < 0 || int(i) >= len(itemNames) {
+		return fmt.Sprintf("Item(%d)", int(i))
+	}
+	return itemNames[i]
+}
+
+const (
+	maxItems      = 3
+	itemClearRows = 3
+	itemDuration  = 10 * time.Second
+	itemSpeedUp   = 4
+)
+
+type ItemHandler interface {
+	OnItem(item Item)
+}
+
+type ItemHandlerFunc func(item Item)
+
+func (f ItemHandlerFunc) OnItem(item Item) {
+	f(item)
+}
+
+func WithItems(chance float64) BoardOption {
+	if chance < 0 || chance > 1 {
+		panic(fmt.Errorf("item chance should be between 0 and 1"))
+	}
+	return func(board *Board) {
+		board.itemChance = chance
+	}
+}
+
+func WithItemSeed(seed int64) BoardOption {
+	return func(board *Board) {
+		board.itemRandomizer = newCountingRand(seed)
+	}
+}
+
+func WithItemHandler(handler ItemHandler) BoardOption {
+	return func(board *Board) {
+		board.itemHandler = handler
+	}
+}
+
+func (b *Board) ApplyItem(item Item) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if b.isOver {
+		return
+	}
+	switch item {
+	case ItemClearRows:
+		b.clearBottomRows(itemClearRows)
+	case ItemFlip:
+		b.flipTiles()
+	case ItemSpeedUp:
+		b.speedUntil = time.Now().Add(itemDuration)
+	case ItemHidePreview:
+		b.previewHiddenUntil = time.Now().Add(itemDuration)
+	}
+}
+
+func (b *Board) awardItem() {
+	if b.itemChance <= 0 || len(b.items) >= maxItems || b.itemRandomizer.Float64() >= b.itemChance {
+		return
+	}
+	b.items = append(b.items, Item(b.itemRandomizer.Intn(len(itemNames))))
+}
+
+func (b *Board) applyUseItem() {
+	if len(b.items) == 0 {
+		return
+	}
+	item := b.items[0]
+	b.items = b.items[1:]
+	if item == ItemClearRows {
+		b.clearBottomRows(itemClearRows)
+		return
+	}
+	b.usedItems = append(b.usedItems, item)
+}
+
+func (b *Board) notifyItems(items []Item) {
+	for _, item := range items {
+		if b.itemHandler != nil {
+			b.itemHandler.OnItem(item)
+		}
+	}
+}
+
+func (b *Board) clearBottomRows(rows int) {
+	rows = minInt(rows, b.height)
+	for y := b.height - 1; y >= 0; y-- {
+		for x := 0; x < b.width; x++ {
+			if y >= rows {
+				b.tiles[y][x] = b.tiles[y-rows][x]
+				b.lockedAt[y][x] = b.lockedAt[y-rows][x]
+			} else {
+				b.tiles[y][x] = TileEmpty
+			}
+		}
+	}
+}
+
+func (b *Board) flipTiles() {
+	for y := 0; y < b.height; y++ {
+		for x := 0; x < b.width/2; x++ {
+			mirror := b.width - 1 - x
+			b.tiles[y][x], b.tiles[y][mirror] = b.tiles[y][mirror], b.tiles[y][x]
+			b.lockedAt[y][x], b.lockedAt[y][mirror] = b.lockedAt[y][mirror], b.lockedAt[y][x]
+		}
+	}
+	for i := 0; i < b.current.Size && b.isOverlapGround(); i++ {
+		b.currentY--
+	}
+	if b.isOverlapGround() {
+		b.isOver = true
+	}
+}
+
+func (b *Board) speedUpGravity(gravity time.Duration) time.Duration {
+	if time.Now().Before(b.speedUntil) {
+		return maxDuration(gravity/itemSpeedUp, Gravity20G)
+	}
+	return gravity
+}
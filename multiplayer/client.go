@@ -6,13 +6,15 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"strings"
 
 	"github.com/JoelOtter/termloop"
 	"github.com/google/uuid"
 	"github.com/jauhararifin/tetris"
+	"github.com/jauhararifin/tetris/termui"
 )
 
-func startClient(host, name, room string) {
+func startClient(host, name, room string, transform tetris.RenderTransform) {
 	idUUID, err := uuid.NewUUID()
 	if err != nil {
 		panic(err)
@@ -67,7 +69,7 @@ func startClient(host, name, room string) {
 	}
 	log.Printf("player1ID=%s player2ID=%s\n", player1ID, player2ID)
 
-	boardEntity1 := NewBoardPlayer(0, 2, initmsg.Width, initmsg.Height, initmsg.Seed[player1ID], func(action tetris.Action) {
+	boardEntity1 := NewBoardPlayer(0, 2, initmsg, initmsg.Seed[player1ID], transform, func(action tetris.Action) {
 		buff := &bytes.Buffer{}
 		actMsg := ActionMessage{Action:action}
 		if err := gob.NewEncoder(buff).Encode(actMsg); err != nil {
@@ -92,7 +94,7 @@ func startClient(host, name, room string) {
 			log.Printf("cannot send user message: %v\n", err)
 		}
 	})
-	boardEntity2 := NewBoardPlayer(initmsg.Width + 15, 2, initmsg.Width, initmsg.Height, initmsg.Seed[player2ID], nil)
+	boardEntity2 := NewBoardPlayer(initmsg.Width + 15, 2, initmsg, initmsg.Seed[player2ID], nil, nil)
 
 	go func() {
 		for {
@@ -124,37 +126,71 @@ type ActionSender func(action tetris.Action)
 type boardPlayer struct {
 	board               *tetris.Board
 	x, y, width, height int
-	score               int
+	ruleset             tetris.Ruleset
+	state               tetris.State
 
 	scoreText    *termloop.Text
+	comboText    *termloop.Text
+	levelText    *termloop.Text
+	itemText     *termloop.Text
 	actionSender ActionSender
 }
 
-func NewBoardPlayer(x, y, width, height int, seed int64, actionSender ActionSender) *boardPlayer {
+func NewBoardPlayer(x, y int, initmsg InitGameMessage, seed int64, transform tetris.RenderTransform, actionSender ActionSender) *boardPlayer {
+	width, height := initmsg.Width, initmsg.Height
+	ruleset, ok := tetris.FindRuleset(initmsg.Ruleset)
+	if !ok {
+		log.Printf("unknown ruleset %s, using %s\n", initmsg.Ruleset, tetris.RulesetGuideline.Name)
+		ruleset = tetris.RulesetGuideline
+	}
+
 	b := &boardPlayer{
-		width:  width,
-		height: height,
-		x:      x,
-		y:      y,
-		score:  0,
+		width:   width,
+		height:  height,
+		x:       x,
+		y:       y,
+		ruleset: ruleset,
 
 		scoreText:    termloop.NewText(x+width+3, y+8, "0", termloop.ColorWhite, termloop.ColorDefault),
+		comboText:    termloop.NewText(x+width+3, y+9, "", termloop.ColorWhite, termloop.ColorDefault),
+		levelText:    termloop.NewText(x+width+3, y+10, "", termloop.ColorWhite, termloop.ColorDefault),
+		itemText:     termloop.NewText(x+width+3, y+11, "", termloop.ColorWhite, termloop.ColorDefault),
 		actionSender: actionSender,
 	}
 
-	b.board = tetris.NewBoard(
+	options := []tetris.BoardOption{
+		tetris.WithRuleset(ruleset),
 		tetris.WithSize(width, height),
-		tetris.WithCompleteHandler(tetris.CompleteHandlerFunc(func(rows int) {
-			b.score += rows * (rows + 1)
-		})),
-		tetris.WithGetter(tetris.NewRandomGetter(seed)),
-	)
+		tetris.WithHiddenRows(initmsg.HiddenRows),
+		tetris.WithGetter(ruleset.Randomizer(seed)),
+	}
+	if transform != nil {
+		options = append(options, tetris.WithRenderTransform(transform))
+	}
+	b.board = tetris.NewBoard(options...)
+	b.state = b.board.GetState()
 
 	return b
 }
 
 func (b *boardPlayer) SetState(state tetris.State) {
 	b.board.SetState(state)
+	b.state = state
+}
+
+func itemStatus(state tetris.State) string {
+	names := make([]string, len(state.Items), len(state.Items))
+	for i, item := range state.Items {
+		names[i] = item.String()
+	}
+	status := ""
+	if len(names) > 0 {
+		status = "Items: " + strings.Join(names, ", ")
+	}
+	if state.SpedUp {
+		status += " Sped up!"
+	}
+	return strings.TrimSpace(status)
 }
 
 func (b *boardPlayer) Tick(ev termloop.Event) {
@@ -162,6 +198,13 @@ func (b *boardPlayer) Tick(ev termloop.Event) {
 		return
 	}
 	if ev.Type == termloop.EventKey {
+		switch ev.Ch {
+		case 'x':
+			b.actionSender(tetris.ActionRotateClockwise)
+		case 'c':
+			b.actionSender(tetris.ActionHold)
+		}
+
 		switch ev.Key {
 		case termloop.KeyArrowLeft:
 			b.actionSender(tetris.ActionGoLeft)
@@ -171,6 +214,8 @@ func (b *boardPlayer) Tick(ev termloop.Event) {
 			b.actionSender(tetris.ActionRotate)
 		case termloop.KeyArrowDown:
 			b.actionSender(tetris.ActionSmash)
+		case termloop.KeySpace:
+			b.actionSender(tetris.ActionUseItem)
 		}
 	}
 }
@@ -201,45 +246,39 @@ func (b *boardPlayer) Draw(s *termloop.Screen) {
 		})
 	}
 
-	for i := 0; i < 6; i++ {
-		s.RenderCell(b.x+b.width+3+i, b.y, &termloop.Cell{
-			Fg: termloop.ColorWhite,
-			Bg: termloop.ColorBlack,
-			Ch: '+',
-		})
-		s.RenderCell(b.x+b.width+3+i, b.y+5, &termloop.Cell{
-			Fg: termloop.ColorWhite,
-			Bg: termloop.ColorBlack,
-			Ch: '+',
-		})
-		s.RenderCell(b.x+b.width+3, b.y+i, &termloop.Cell{
-			Fg: termloop.ColorWhite,
+	termui.DrawBox(s, b.x+b.width+3, b.y)
+	if b.ruleset.Hold {
+		termui.DrawBox(s, b.x+b.width+3, b.y+13)
+	}
+
+	for i := 0; i < b.height; i++ {
+		ch := rune(0)
+		if b.height-i <= b.state.PendingGarbage {
+			ch = '|'
+		}
+		s.RenderCell(b.x+b.width+2, b.y+1+i, &termloop.Cell{
+			Fg: termloop.ColorRed,
 			Bg: termloop.ColorBlack,
-			Ch: '+',
-		})
-		s.RenderCell(b.x+b.width+8, b.y+i, &termloop.Cell{
-			Fg: termloop.ColorWhite,
-			Bg: termloop.ColorBlack,
-			Ch: '+',
+			Ch: ch,
 		})
 	}
 
-	b.scoreText.SetText(fmt.Sprintf("Score: %d", b.score))
+	b.scoreText.SetText(fmt.Sprintf("Score: %d", b.state.Score))
 	b.scoreText.Draw(s)
+	b.comboText.SetText(b.state.ComboStatus())
+	b.comboText.Draw(s)
+	b.levelText.SetText(fmt.Sprintf("Level: %d Lines: %d", b.state.Level, b.state.Lines))
+	b.levelText.Draw(s)
+	b.itemText.SetText(itemStatus(b.state))
+	b.itemText.Draw(s)
 
-	next := b.board.Next()
-	for y := 0; y < 4; y++ {
-		for x := 0; x < 4; x++ {
-			ch := rune(0)
-			if next[y][x] == 1 {
-				ch = '@'
-			}
-			s.RenderCell(b.x+b.width+4+x, b.y+1+y, &termloop.Cell{
-				Fg: termloop.ColorWhite,
-				Bg: termloop.ColorBlack,
-				Ch: ch,
-			})
-		}
+	next := b.state.Next
+	if b.state.PreviewHidden {
+		next = tetris.Tetromino{}
+	}
+	termui.DrawTetromino(s, b.x+b.width+4, b.y+1, next, termloop.ColorWhite)
+	if b.ruleset.Hold {
+		termui.DrawTetromino(s, b.x+b.width+4, b.y+14, b.state.Hold, termloop.ColorWhite)
 	}
 
 	tiles := b.board.Render()
@@ -1,18 +1,37 @@
 package main
 
-import "flag"
+import (
+	"flag"
+	"log"
+
+	"github.com/jauhararifin/tetris"
+)
 
 func main() {
 	isServer := flag.Bool("server", false, "run server")
 	host := flag.String("host", "localhost:8123", "host")
 	name := flag.String("name", "", "name")
 	room := flag.String("room", "", "room")
+	rules := flag.String("rules", tetris.RulesetGuideline.Name, "ruleset used by the server: guideline, classic, tgm or master")
+	items := flag.Float64("items", 0, "chance a clear awards an item in versus games, 0 disables items")
+	view := flag.String("view", "normal", "render mode: normal, invisible[=delay] or fog[=rows]")
 	flag.Parse()
 
 	if *isServer {
-		startServer()
+		ruleset, ok := tetris.FindRuleset(*rules)
+		if !ok {
+			log.Fatalf("unknown ruleset: %s\n", *rules)
+		}
+		if *items < 0 || *items > 1 {
+			log.Fatalf("item chance should be between 0 and 1\n")
+		}
+		startServer(ruleset, *items)
 	} else {
-		startClient(*host, *name, *room)
+		transform, err := tetris.ParseRenderTransform(*view)
+		if err != nil {
+			log.Fatalf("cannot parse render mode: %v\n", err)
+		}
+		startClient(*host, *name, *room, transform)
 	}
 }
 
@@ -30,18 +30,22 @@ type Player struct {
 type Room struct {
 	m                *sync.Mutex
 	randomizer       *rand.Rand
+	ruleset          tetris.Ruleset
+	itemChance       float64
 	player1, player2 Player
 	board1, board2   *tetris.Board
 	sender           MessageSender
 	isStarted        bool
 	updateTicker     *time.Ticker
-	gameTicker       *time.Ticker
+	stop             chan struct{}
 }
 
-func NewRoom(sender MessageSender) *Room {
+func NewRoom(sender MessageSender, ruleset tetris.Ruleset, itemChance float64) *Room {
 	return &Room{
 		m:            &sync.Mutex{},
 		randomizer:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		ruleset:      ruleset,
+		itemChance:   itemChance,
 		player1:      Player{},
 		player2:      Player{},
 		board1:       nil,
@@ -49,7 +53,7 @@ func NewRoom(sender MessageSender) *Room {
 		sender:       sender,
 		isStarted:    false,
 		updateTicker: nil,
-		gameTicker:   nil,
+		stop:         nil,
 	}
 }
 
@@ -93,6 +97,8 @@ type InitGameMessage struct {
 	Seed          map[string]int64
 	FPS           int
 	Width, Height int
+	HiddenRows    int
+	Ruleset       string
 }
 
 func (r *Room) initGame() {
@@ -100,24 +106,45 @@ func (r *Room) initGame() {
 	defer r.m.Unlock()
 
 	width, height := 10, 24
+	hiddenRows := 2
 	fps := 24
+	garbageMessiness := 0.3
+	garbageDelay := 1 * time.Second
 	randA, randB := r.randomizer.Int63(), r.randomizer.Int63()
+	garbageA, garbageB := r.randomizer.Int63(), r.randomizer.Int63()
+	itemA, itemB := r.randomizer.Int63(), r.randomizer.Int63()
 	r.board1 = tetris.NewBoard(
 		tetris.WithSize(width, height),
-		tetris.WithGetter(tetris.NewRandomGetter(randA)),
-		tetris.WithCompleteHandler(tetris.CompleteHandlerFunc(func(rows int) {
-			for i := 0; i < rows; i++ {
-				r.board2.Apply(tetris.ActionFill)
-			}
+		tetris.WithHiddenRows(hiddenRows),
+		tetris.WithRuleset(r.ruleset),
+		tetris.WithGetter(r.ruleset.Randomizer(randA)),
+		tetris.WithGarbageSeed(garbageA),
+		tetris.WithGarbageMessiness(garbageMessiness),
+		tetris.WithGarbageDelay(garbageDelay),
+		tetris.WithClearHandler(tetris.ClearHandlerFunc(func(event tetris.ClearEvent) {
+			r.board2.ReceiveGarbage(event.Attack)
+		})),
+		tetris.WithItems(r.itemChance),
+		tetris.WithItemSeed(itemA),
+		tetris.WithItemHandler(tetris.ItemHandlerFunc(func(item tetris.Item) {
+			r.board2.ApplyItem(item)
 		})),
 	)
 	r.board2 = tetris.NewBoard(
 		tetris.WithSize(width, height),
-		tetris.WithGetter(tetris.NewRandomGetter(randB)),
-		tetris.WithCompleteHandler(tetris.CompleteHandlerFunc(func(rows int) {
-			for i := 0; i < rows; i++ {
-				r.board1.Apply(tetris.ActionFill)
-			}
+		tetris.WithHiddenRows(hiddenRows),
+		tetris.WithRuleset(r.ruleset),
+		tetris.WithGetter(r.ruleset.Randomizer(randB)),
+		tetris.WithGarbageSeed(garbageB),
+		tetris.WithGarbageMessiness(garbageMessiness),
+		tetris.WithGarbageDelay(garbageDelay),
+		tetris.WithClearHandler(tetris.ClearHandlerFunc(func(event tetris.ClearEvent) {
+			r.board1.ReceiveGarbage(event.Attack)
+		})),
+		tetris.WithItems(r.itemChance),
+		tetris.WithItemSeed(itemB),
+		tetris.WithItemHandler(tetris.ItemHandlerFunc(func(item tetris.Item) {
+			r.board1.ApplyItem(item)
 		})),
 	)
 	r.isStarted = true
@@ -128,6 +155,9 @@ func (r *Room) initGame() {
 		FPS:    fps,
 		Width:  width,
 		Height: height,
+
+		HiddenRows: hiddenRows,
+		Ruleset:    r.ruleset.Name,
 	}); err != nil {
 		log.Printf("cannot encode init message: %v\n", err)
 	}
@@ -152,14 +182,9 @@ func (r *Room) startGame(fps int) {
 
 	ms := time.Duration(1000 / fps)
 	r.updateTicker = time.NewTicker(ms * time.Millisecond)
-	r.gameTicker = time.NewTicker(750 * time.Millisecond)
-
-	go func() {
-		for range r.gameTicker.C {
-			r.board1.Apply(tetris.ActionTick)
-			r.board2.Apply(tetris.ActionTick)
-		}
-	}()
+	r.stop = make(chan struct{})
+	go tickBoard(r.board1, r.stop)
+	go tickBoard(r.board2, r.stop)
 
 	go func() {
 		for range r.updateTicker.C {
@@ -193,6 +218,23 @@ func (r *Room) stopGame() {
 	if r.updateTicker != nil {
 		r.updateTicker.Stop()
 	}
+	if r.stop != nil {
+		close(r.stop)
+		r.stop = nil
+	}
+}
+
+func tickBoard(board *tetris.Board, stop chan struct{}) {
+	for {
+		timer := time.NewTimer(board.TickDelay())
+		select {
+		case <-timer.C:
+			board.Apply(tetris.ActionTick)
+		case <-stop:
+			timer.Stop()
+			return
+		}
+	}
 }
 
 type ActionMessage struct {
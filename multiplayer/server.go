@@ -5,9 +5,13 @@ import (
 	"encoding/gob"
 	"log"
 	"net"
+
+	"github.com/jauhararifin/tetris"
 )
 
 type server struct {
+	ruleset  tetris.Ruleset
+	items    float64
 	conn     *net.UDPConn
 	rooms    map[string]*Room
 	userAddr map[string]*net.UDPAddr
@@ -17,7 +21,7 @@ type server struct {
 func (s *server) OnUserJoin(id, name, room string, addr *net.UDPAddr) {
 	r, ok := s.rooms[room]
 	if !ok {
-		r = NewRoom(s)
+		r = NewRoom(s, s.ruleset, s.items)
 		s.rooms[room] = r
 	}
 
@@ -68,7 +72,7 @@ type UserMessage struct {
 	RoomMessage *RoomMessage
 }
 
-func startServer() {
+func startServer(ruleset tetris.Ruleset, items float64) {
 	s, err := net.ResolveUDPAddr("udp4", ":8123")
 	if err != nil {
 		panic(err)
@@ -80,6 +84,8 @@ func startServer() {
 	}
 
 	gameServer := &server{
+		ruleset:  ruleset,
+		items:    items,
 		conn:     conn,
 		rooms:    make(map[string]*Room),
 		userAddr: make(map[string]*net.UDPAddr),
@@ -0,0 +1,231 @@
+package tetris
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type Tetromino struct {
+	Size   int
+	Blocks string
+}
+
+func NewTetromino(rows ...string) Tetromino {
+	size := len(rows)
+	for _, row := range rows {
+		size = maxInt(size, len(row))
+	}
+
+	blocks := []byte(strings.Repeat(".", size*size))
+	for y, row := range rows {
+		for x := range row {
+			if row[x] == '#' {
+				blocks[y*size+x] = '#'
+			}
+		}
+	}
+	return Tetromino{Size: size, Blocks: string(blocks)}
+}
+
+func newTetrominoFromCells(size int, cells [][2]int) Tetromino {
+	blocks := []byte(strings.Repeat(".", size*size))
+	for _, cell := range cells {
+		blocks[cell[0]*size+cell[1]] = '#'
+	}
+	return Tetromino{Size: size, Blocks: string(blocks)}
+}
+
+func (t Tetromino) Block(y, x int) bool {
+	if y < 0 || y >= t.Size || x < 0 || x >= t.Size {
+		return false
+	}
+	return t.Blocks[y*t.Size+x] == '#'
+}
+
+func (t Tetromino) String() string {
+	rows := make([]string, t.Size, t.Size)
+	for y := range rows {
+		rows[y] = t.Blocks[y*t.Size : (y+1)*t.Size]
+	}
+	return strings.Join(rows, "\n")
+}
+
+func LoadTetrominos(path string) ([]Tetromino, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseTetrominos(f)
+}
+
+func ParseTetrominos(r io.Reader) ([]Tetromino, error) {
+	pieces := make([]Tetromino, 0, 0)
+	rows := make([]string, 0, 0)
+	flush := func(line int) error {
+		if len(rows) == 0 {
+			return nil
+		}
+		t := NewTetromino(rows...)
+		if !isConnected(t) {
+			return fmt.Errorf("piece ending at line %d is not connected", line)
+		}
+		pieces = append(pieces, t)
+		rows = rows[:0]
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(text, "//") {
+			continue
+		}
+		if text == "" {
+			if err := flush(line - 1); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if strings.Trim(text, "#.") != "" {
+			return nil, fmt.Errorf("line %d: piece rows may only contain '#' and '.'", line)
+		}
+		rows = append(rows, text)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(line); err != nil {
+		return nil, err
+	}
+	return pieces, nil
+}
+
+func isConnected(t Tetromino) bool {
+	cells := tetrominoCells(t)
+	if len(cells) == 0 {
+		return false
+	}
+
+	visited := map[[2]int]bool{cells[0]: true}
+	stack := [][2]int{cells[0]}
+	for len(stack) > 0 {
+		cell := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, d := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			next := [2]int{cell[0] + d[0], cell[1] + d[1]}
+			if t.Block(next[0], next[1]) && !visited[next] {
+				visited[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+	return len(visited) == len(cells)
+}
+
+func mustParseTetrominos(definitions string) []Tetromino {
+	pieces, err := ParseTetrominos(strings.NewReader(definitions))
+	if err != nil {
+		panic(err)
+	}
+	return pieces
+}
+
+var (
+	Monomino = NewTetromino("#")
+
+	Pentominoes = mustParseTetrominos(`
+// F and its mirror
+.##
+##.
+.#.
+
+##.
+.##
+.#.
+
+// I
+..#..
+..#..
+..#..
+..#..
+..#..
+
+// L and J
+.#..
+.#..
+.#..
+.##.
+
+..#.
+..#.
+..#.
+.##.
+
+// N and its mirror
+.#..
+.#..
+##..
+#...
+
+..#.
+..#.
+..##
+...#
+
+// P and its mirror
+##.
+##.
+#..
+
+##.
+##.
+.#.
+
+// T, U, V, W, X
+###
+.#.
+.#.
+
+#.#
+###
+...
+
+#..
+#..
+###
+
+#..
+##.
+.##
+
+.#.
+###
+.#.
+
+// Y and its mirror
+..#.
+.##.
+..#.
+..#.
+
+.#..
+.##.
+.#..
+.#..
+
+// Z and S
+##.
+.#.
+.##
+
+.##
+.#.
+##.
+`)
+)
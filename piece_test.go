@@ -0,0 +1,69 @@
+package tetris
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTetrominos(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Tetromino
+		err   string
+	}{
+		{
+			name:  "empty",
+			input: "",
+			want:  []Tetromino{},
+		},
+		{
+			name:  "single piece",
+			input: "##\n##\n",
+			want:  []Tetromino{NewTetromino("##", "##")},
+		},
+		{
+			name:  "comments and blank lines",
+			input: "// monomino\n#\n\n\n// tromino\n  .#.  \n  ###  \n",
+			want:  []Tetromino{NewTetromino("#"), NewTetromino(".#.", "###")},
+		},
+		{
+			name:  "no trailing newline",
+			input: "#.\n##",
+			want:  []Tetromino{NewTetromino("#.", "##")},
+		},
+		{
+			name:  "invalid character",
+			input: "#.\n#x\n",
+			err:   "line 2: piece rows may only contain '#' and '.'",
+		},
+		{
+			name:  "disconnected piece",
+			input: "#\n\n#.#\n",
+			err:   "piece ending at line 3 is not connected",
+		},
+		{
+			name:  "piece without blocks",
+			input: "...\n\n",
+			err:   "piece ending at line 1 is not connected",
+		},
+	}
+
+	for _, test := range tests {
+		pieces, err := ParseTetrominos(strings.NewReader(test.input))
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(pieces, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, pieces, test.want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package tetris
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+type PieceDefinition struct {
+	Name      string              `json:"name" toml:"name"`
+	Shape     []string            `json:"shape" toml:"shape"`
+	Rotations [][]string          `json:"rotations" toml:"rotations"`
+	Kicks     map[string][][2]int `json:"kicks" toml:"kicks"`
+	Spawn     *SpawnRule          `json:"spawn" toml:"spawn"`
+	Color     string              `json:"color" toml:"color"`
+}
+
+type PieceSet struct {
+	Name   string              `json:"name" toml:"name"`
+	Kicks  map[string][][2]int `json:"kicks" toml:"kicks"`
+	Pieces []PieceDefinition   `json:"pieces" toml:"pieces"`
+
+	states [][]Tetromino
+}
+
+func LoadPieceSet(path string) (*PieceSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ParsePieceSetJSON(f)
+	case ".toml":
+		return ParsePieceSetTOML(f)
+	}
+	return nil, fmt.Errorf("unknown piece set format: %s", path)
+}
+
+func ParsePieceSetJSON(r io.Reader) (*PieceSet, error) {
+	set := &PieceSet{}
+	if err := json.NewDecoder(r).Decode(set); err != nil {
+		return nil, err
+	}
+	return set, set.compile()
+}
+
+func ParsePieceSetTOML(r io.Reader) (*PieceSet, error) {
+	set := &PieceSet{}
+	if _, err := toml.DecodeReader(r, set); err != nil {
+		return nil, err
+	}
+	return set, set.compile()
+}
+
+func (s *PieceSet) compile() error {
+	if len(s.Pieces) == 0 {
+		return fmt.Errorf("piece set has no pieces")
+	}
+
+	s.states = make([][]Tetromino, len(s.Pieces), len(s.Pieces))
+	for i, piece := range s.Pieces {
+		rows := append([][]string{piece.Shape}, piece.Rotations...)
+		size := 0
+		for _, state := range rows {
+			size = maxInt(size, NewTetromino(state...).Size)
+		}
+
+		states := make([]Tetromino, 0, 4)
+		for _, state := range rows {
+			t := NewTetromino(padRows(state, size)...)
+			if !isConnected(t) {
+				return fmt.Errorf("piece %q: rotation state %d is not a connected shape", piece.Name, len(states))
+			}
+			if len(states) > 0 && len(tetrominoCells(t)) != len(tetrominoCells(states[0])) {
+				return fmt.Errorf("piece %q: rotation states have different block counts", piece.Name)
+			}
+			states = append(states, t)
+		}
+		for len(states) < 4 && len(piece.Rotations) == 0 {
+			states = append(states, rotateTetrominoTimes(states[len(states)-1], 3))
+		}
+		if len(states) != 1 && len(states) != 2 && len(states) != 4 {
+			return fmt.Errorf("piece %q: expected 1, 2 or 4 rotation states, got %d", piece.Name, len(states))
+		}
+
+		for key := range piece.Kicks {
+			if _, _, ok := parseKickKey(key); !ok {
+				return fmt.Errorf("piece %q: invalid kick key %q, expected \"from>to\"", piece.Name, key)
+			}
+		}
+		s.states[i] = states
+	}
+
+	for key := range s.Kicks {
+		if _, _, ok := parseKickKey(key); !ok {
+			return fmt.Errorf("invalid kick key %q, expected \"from>to\"", key)
+		}
+	}
+	return nil
+}
+
+func padRows(rows []string, size int) []string {
+	padded := make([]string, size, size)
+	for y := range padded {
+		if y < len(rows) {
+			padded[y] = rows[y]
+		}
+		padded[y] += strings.Repeat(".", size-len(padded[y]))
+	}
+	return padded
+}
+
+func parseKickKey(key string) (from, to int, ok bool) {
+	if _, err := fmt.Sscanf(key, "%d>%d", &from, &to); err != nil {
+		return 0, 0, false
+	}
+	return from, to, from >= 0 && from < 4 && to >= 0 && to < 4
+}
+
+func (s *PieceSet) Tetrominos() []Tetromino {
+	tetrominos := make([]Tetromino, len(s.states), len(s.states))
+	for i, states := range s.states {
+		tetrominos[i] = states[0]
+	}
+	return tetrominos
+}
+
+func (s *PieceSet) Color(t Tetromino) string {
+	if i, _, ok := s.find(t, -1); ok {
+		return s.Pieces[i].Color
+	}
+	return ""
+}
+
+func (s *PieceSet) find(t Tetromino, rotation int) (piece, state int, ok bool) {
+	t = normalizeTetromino(t)
+	for i, states := range s.states {
+		for j, candidate := range states {
+			if rotation >= 0 && j != rotation%len(states) {
+				continue
+			}
+			if normalizeTetromino(candidate) == t {
+				return i, j, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func (s *PieceSet) spawnRules(fallback SpawnRules) SpawnRules {
+	rules := make(SpawnRules, len(fallback)+len(s.states))
+	for t, rule := range fallback {
+		rules[t] = rule
+	}
+	for i, piece := range s.Pieces {
+		if piece.Spawn != nil {
+			rules[s.states[i][0]] = SpawnRule{Rotation: 0, Column: piece.Spawn.Column, Row: piece.Spawn.Row}
+		}
+	}
+	return rules
+}
+
+type pieceSetRotation struct {
+	set      *PieceSet
+	fallback RotationSystem
+}
+
+func (r pieceSetRotation) Rotate(t Tetromino, rotation int, clockwise bool) (Tetromino, [][2]int) {
+	i, from, ok := r.set.find(t, rotation)
+	if !ok {
+		i, from, ok = r.set.find(t, -1)
+	}
+	if !ok {
+		return r.fallback.Rotate(t, rotation, clockwise)
+	}
+	piece := r.set.Pieces[i]
+	if len(piece.Rotations) == 0 && piece.Kicks == nil && r.set.Kicks == nil {
+		return r.fallback.Rotate(t, rotation, clockwise)
+	}
+
+	states := r.set.states[i]
+	to := (from + 1) % len(states)
+	if !clockwise {
+		to = (from + len(states) - 1) % len(states)
+	}
+
+	top, left, _, _ := tetrominoBounds(t)
+	stateTop, stateLeft, _, _ := tetrominoBounds(states[from])
+	offsets, ok := piece.Kicks[fmt.Sprintf("%d>%d", from, to)]
+	if !ok {
+		offsets, ok = r.set.Kicks[fmt.Sprintf("%d>%d", from, to)]
+	}
+	if !ok {
+		offsets = [][2]int{{0, 0}}
+	}
+
+	kicks := make([][2]int, len(offsets), len(offsets))
+	for i, offset := range offsets {
+		kicks[i] = [2]int{left - stateLeft + offset[0], top - stateTop - offset[1]}
+	}
+	return states[to], kicks
+}
+
+func WithPieceSet(set *PieceSet) BoardOption {
+	return func(board *Board) {
+		board.tetrominos = set.Tetrominos()
+		board.ruleOverrides = append(board.ruleOverrides, func(board *Board) {
+			board.rotation = pieceSetRotation{set: set, fallback: board.rotation}
+			board.spawnRules = set.spawnRules(board.spawnRules)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package tetris
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePieceSetTOML(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		states []int
+		err    string
+	}{
+		{
+			name:   "automatic rotations",
+			input:  "[[pieces]]\nname = \"T\"\nshape = [\".#.\", \"###\"]\n",
+			states: []int{4},
+		},
+		{
+			name:   "explicit rotations",
+			input:  "[[pieces]]\nname = \"I\"\nshape = [\"###\"]\nrotations = [[\"#\", \"#\", \"#\"]]\n",
+			states: []int{2},
+		},
+		{
+			name:   "kicks and spawn",
+			input:  "[kicks]\n\"0>1\" = [[0, 0], [1, 0]]\n\n[[pieces]]\nname = \"O\"\nshape = [\"##\", \"##\"]\nspawn = { column = 4, row = -1 }\nkicks = { \"3>0\" = [[0, 1]] }\n",
+			states: []int{4},
+		},
+		{
+			name:  "no pieces",
+			input: "name = \"empty\"\n",
+			err:   "piece set has no pieces",
+		},
+		{
+			name:  "disconnected shape",
+			input: "[[pieces]]\nname = \"gap\"\nshape = [\"#.#\"]\n",
+			err:   "piece \"gap\": rotation state 0 is not a connected shape",
+		},
+		{
+			name:  "disconnected rotation",
+			input: "[[pieces]]\nname = \"I\"\nshape = [\"##\"]\nrotations = [[\"#.\", \".#\"]]\n",
+			err:   "piece \"I\": rotation state 1 is not a connected shape",
+		},
+		{
+			name:  "different block counts",
+			input: "[[pieces]]\nname = \"I\"\nshape = [\"###\"]\nrotations = [[\"#\", \"#\"]]\n",
+			err:   "piece \"I\": rotation states have different block counts",
+		},
+		{
+			name:  "three rotation states",
+			input: "[[pieces]]\nname = \"I\"\nshape = [\"##\"]\nrotations = [[\"#\", \"#\"], [\"##\"]]\n",
+			err:   "piece \"I\": expected 1, 2 or 4 rotation states, got 3",
+		},
+		{
+			name:  "invalid piece kick key",
+			input: "[[pieces]]\nname = \"O\"\nshape = [\"##\", \"##\"]\nkicks = { \"0-1\" = [[0, 0]] }\n",
+			err:   "piece \"O\": invalid kick key \"0-1\", expected \"from>to\"",
+		},
+		{
+			name:  "invalid set kick key",
+			input: "[kicks]\n\"4>0\" = [[0, 0]]\n\n[[pieces]]\nname = \"O\"\nshape = [\"##\", \"##\"]\n",
+			err:   "invalid kick key \"4>0\", expected \"from>to\"",
+		},
+		{
+			name:  "malformed toml",
+			input: "[[pieces]\nname = \"O\"\n",
+			err:   "Near line 1",
+		},
+	}
+
+	for _, test := range tests {
+		set, err := ParsePieceSetTOML(strings.NewReader(test.input))
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if len(set.states) != len(test.states) {
+			t.Errorf("%s: got %d pieces, want %d", test.name, len(set.states), len(test.states))
+			continue
+		}
+		for i, states := range set.states {
+			if len(states) != test.states[i] {
+				t.Errorf("%s: piece %d has %d rotation states, want %d", test.name, i, len(states), test.states[i])
+			}
+		}
+	}
+}
+
+func TestParsePieceSetJSON(t *testing.T) {
+	set, err := ParsePieceSetJSON(strings.NewReader(`{"name": "domino", "pieces": [{"name": "D", "shape": ["##"], "color": "red"}]}`))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if got := set.Color(NewTetromino("#", "#")); got != "red" {
+		t.Errorf("got color %q for a rotated domino, want red", got)
+	}
+
+	if _, err := ParsePieceSetJSON(strings.NewReader(`{"pieces": [`)); err == nil {
+		t.Errorf("expected an error for truncated json")
+	}
+	if _, err := ParsePieceSetJSON(strings.NewReader(`{"pieces": []}`)); err == nil {
+		t.Errorf("expected an error for an empty piece list")
+	}
+}
+
+func TestParseKickKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		from, to int
+		ok       bool
+	}{
+		{"0>1", 0, 1, true},
+		{"3>0", 3, 0, true},
+		{"2>2", 2, 2, true},
+		{"4>0", 0, 0, false},
+		{"0>-1", 0, 0, false},
+		{"0-1", 0, 0, false},
+		{"a>b", 0, 0, false},
+		{"", 0, 0, false},
+	}
+
+	for _, test := range tests {
+		from, to, ok := parseKickKey(test.key)
+		if ok != test.ok || ok && (from != test.from || to != test.to) {
+			t.Errorf("parseKickKey(%q) = %d, %d, %v, want %d, %d, %v", test.key, from, to, ok, test.from, test.to, test.ok)
+		}
+	}
+}
+
+func TestLoadPieceSet(t *testing.T) {
+	set, err := LoadPieceSet("playtetris/pieces.toml")
+	if err != nil {
+		t.Fatalf("cannot load the example piece set: %v", err)
+	}
+	if len(set.Pieces) != 9 {
+		t.Fatalf("got %d pieces, want 9", len(set.Pieces))
+	}
+	colors := map[string]bool{"black": true, "red": true, "green": true, "yellow": true, "blue": true, "magenta": true, "cyan": true, "white": true}
+	for i, piece := range set.Pieces {
+		if !colors[piece.Color] {
+			t.Errorf("piece %q has unknown color %q", piece.Name, piece.Color)
+		}
+		if len(set.states[i]) != 4 {
+			t.Errorf("piece %q has %d rotation states, want 4", piece.Name, len(set.states[i]))
+		}
+	}
+
+	if spawn := set.Pieces[0].Spawn; spawn == nil || spawn.Column != 3 || spawn.Row != -1 {
+		t.Errorf("got spawn %v for the I piece, want column 3 and row -1", spawn)
+	}
+	if kicks := set.Pieces[8].Kicks["0>1"]; len(kicks) != 2 || kicks[1] != [2]int{0, 1} {
+		t.Errorf("got kicks %v for the tromino L, want [[0 0] [0 1]]", kicks)
+	}
+
+	board := NewBoard(WithPieceSet(set), WithSeed(1))
+	for i := 0; i < 20; i++ {
+		board.Apply(ActionRotateClockwise)
+		board.Apply(ActionSmash)
+		board.Apply(ActionTick)
+	}
+
+	if _, err := LoadPieceSet("playtetris/puzzles.txt"); err == nil || !strings.Contains(err.Error(), "unknown piece set format") {
+		t.Errorf("got error %v for a text file, want an unknown format error", err)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jauhararifin/tetris"
+)
+
+const dailyLayout = "2006-01-02"
+
+type dailyMode struct {
+	gameMode
+	m           *sync.Mutex
+	date        string
+	variant     string
+	seed        int64
+	scoresPath  string
+	leaderboard []string
+}
+
+func newDailyMode(mode gameMode, date, variant, scoresPath string) (*dailyMode, error) {
+	day, err := time.Parse(dailyLayout, date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid daily date %q: %v", date, err)
+	}
+	return &dailyMode{
+		gameMode:    mode,
+		m:           &sync.Mutex{},
+		date:        date,
+		variant:     variant,
+		seed:        dailySeed(day),
+		scoresPath:  scoresPath,
+		leaderboard: nil,
+	}, nil
+}
+
+func dailyVariant(config gameConfig) string {
+	variant := config.Ruleset.Name
+	if config.StartLevel >= 0 {
+		variant += fmt.Sprintf(" level=%d", config.StartLevel)
+	}
+	if config.PiecesPath != "" {
+		variant += " pieces=" + config.PiecesPath
+	}
+	if config.Big {
+		variant += " big"
+	}
+	if config.Cascade {
+		variant += " cascade"
+	}
+	return variant
+}
+
+func (d *dailyMode) table() string {
+	settings := d.gameMode.Settings()
+	table := fmt.Sprintf("daily %s %s %s", d.date, settings.Name, d.variant)
+	if settings.Lines > 0 {
+		table += fmt.Sprintf(" lines=%d", settings.Lines)
+	}
+	if settings.TimeLimit > 0 {
+		table += fmt.Sprintf(" time=%s", settings.TimeLimit)
+	}
+	if settings.Feed > 0 {
+		table += fmt.Sprintf(" feed=%d", settings.Feed)
+	}
+	return table
+}
+
+func dailySeed(day time.Time) int64 {
+	return int64(day.Year()*10000 + int(day.Month())*100 + day.Day())
+}
+
+func (d *dailyMode) Settings() modeSettings {
+	settings := d.gameMode.Settings()
+	settings.Daily = d.date
+	return settings
+}
+
+func (d *dailyMode) Options() []tetris.BoardOption {
+	return append(d.gameMode.Options(), tetris.WithSeed(d.seed))
+}
+
+func (d *dailyMode) Status(state tetris.State) string {
+	return fmt.Sprintf("Daily %s %s", d.date, d.gameMode.Status(state))
+}
+
+func (d *dailyMode) Progress() modeProgress {
+	if keeper, ok := d.gameMode.(progressKeeper); ok {
+		return keeper.Progress()
+	}
+	return modeProgress{}
+}
+
+func (d *dailyMode) Restore(progress modeProgress) {
+	if keeper, ok := d.gameMode.(progressKeeper); ok {
+		keeper.Restore(progress)
+	}
+}
+
+func (d *dailyMode) Results(state tetris.State) []string {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	return append(d.gameMode.Results(state), d.leaderboard...)
+}
+
+func (d *dailyMode) Record(state tetris.State) {
+	name := d.gameMode.Settings().Name
+	entry := scoreEntry{Score: state.Score, Lines: state.Lines, Time: state.Elapsed, Date: time.Now(), Completed: state.IsCompleted}
+	entries, rank, err := recordScore(d.scoresPath, d.table(), entry, dailyRanking(name))
+
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if err != nil {
+		d.leaderboard = []string{fmt.Sprintf("Cannot record daily score: %v", err)}
+		return
+	}
+	d.leaderboard = []string{fmt.Sprintf("Daily %s %s:", d.date, name)}
+	for i, entry := range entries {
+		marker := " "
+		if i == rank {
+			marker = "*"
+		}
+		d.leaderboard = append(d.leaderboard, fmt.Sprintf("%s%2d. %s", marker, i+1, dailyScore(name, entry)))
+	}
+}
+
+func dailyRanking(name string) func(a, b scoreEntry) bool {
+	switch name {
+	case "sprint", "dig":
+		return func(a, b scoreEntry) bool {
+			if a.Completed != b.Completed {
+				return a.Completed
+			}
+			if !a.Completed {
+				return a.Lines > b.Lines
+			}
+			return a.Time < b.Time
+		}
+	case "survival":
+		return func(a, b scoreEntry) bool {
+			return a.Time > b.Time
+		}
+	}
+	return func(a, b scoreEntry) bool {
+		return a.Score > b.Score
+	}
+}
+
+func dailyScore(name string, entry scoreEntry) string {
+	switch name {
+	case "sprint", "dig":
+		if entry.Completed {
+			return formatDuration(entry.Time)
+		}
+		return fmt.Sprintf("DNF (%d lines)", entry.Lines)
+	case "survival":
+		return formatDuration(entry.Time)
+	}
+	return fmt.Sprintf("%d (%d lines)", entry.Score, entry.Lines)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jauhararifin/tetris"
+)
+
+func (b *boardPlayer) importFumen(data string) error {
+	state := b.board.GetState()
+	pages, err := tetris.DecodeFumen(data, len(state.Tiles))
+	if err != nil {
+		return err
+	}
+	for y := range state.Tiles {
+		if len(state.Tiles[y]) != len(pages[0].State.Tiles[y]) {
+			return fmt.Errorf("fumen fields are %d columns wide, the board has %d", len(pages[0].State.Tiles[y]), len(state.Tiles[y]))
+		}
+	}
+	state.Tiles = pages[0].State.Tiles
+	b.board.SetState(state)
+
+	if b.history != nil {
+		b.history = newHistory(b.Save())
+	}
+	return nil
+}
+
+func (b *boardPlayer) exportFumen(path string) error {
+	snapshots := []*savedGame{b.Save()}
+	if b.history != nil {
+		snapshots = b.history.played()
+	}
+
+	pages := make([]tetris.FumenPage, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		pages = append(pages, tetris.FumenPage{State: snapshot.State})
+	}
+	data, err := tetris.EncodeFumen(pages)
+	if err != nil {
+		return err
+	}
+
+	if path == "-" {
+		_, err = fmt.Println(data)
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintln(f, data)
+	return err
+}
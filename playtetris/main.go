@@ -1,71 +1,347 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"log"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/JoelOtter/termloop"
 	"github.com/jauhararifin/tetris"
+	"github.com/jauhararifin/tetris/termui"
 )
 
 func main() {
+	savePath := flag.String("save", "tetris.sav", "save file")
+	scoresPath := flag.String("scores", "tetris.scores", "leaderboard file")
+	resume := flag.Bool("resume", false, "resume the saved game")
+	practice := flag.Bool("practice", false, "practice mode, press z to undo and y to redo a placement")
+	rules := flag.String("rules", tetris.RulesetGuideline.Name, "ruleset: guideline, classic, tgm or master")
+	startLevel := flag.Int("level", -1, "start level, defaults to the ruleset's")
+	piecesPath := flag.String("pieces", "", "piece set definition file (.json or .toml)")
+	modeName := flag.String("mode", "endless", "game mode: endless, sprint, ultra, marathon, dig, survival or puzzle")
+	lines := flag.Int("lines", 0, "line goal or garbage rows to dig, defaults to the mode's")
+	feed := flag.Int("feed", 0, "garbage rows kept on the board while digging, 0 starts with every row")
+	timeLimit := flag.Duration("time", 0, "time limit, defaults to the mode's")
+	view := flag.String("view", "normal", "render mode: normal, invisible[=delay] or fog[=rows]")
+	cascade := flag.Bool("cascade", false, "cascade gravity, loose chunks fall after a clear and can chain")
+	big := flag.Bool("big", false, "big mode, every piece cell takes 2x2 blocks, needs an even field size and cannot be used with puzzles or fumen")
+	puzzlesPath := flag.String("puzzles", "puzzles.txt", "puzzle pack used by the puzzle mode")
+	daily := flag.Bool("daily", false, "play today's daily challenge, everyone gets the same pieces")
+	fumen := flag.String("fumen", "", "start from the field of a fumen diagram (v115@...)")
+	exportPath := flag.String("export", "", "write the final board as fumen to this file on exit, - for stdout, practice mode exports every placement as a page")
+	flag.Parse()
+
+	var saved *savedGame
+	if *resume {
+		var err error
+		if saved, err = loadGame(*savePath); err != nil {
+			log.Fatalf("cannot load saved game: %v\n", err)
+		}
+		*rules = saved.Ruleset
+		*piecesPath = saved.Pieces
+		*startLevel = saved.StartLevel
+		*big = saved.Big
+		*cascade = saved.Cascade
+		*modeName, *lines, *timeLimit, *feed = saved.Mode.Name, saved.Mode.Lines, saved.Mode.TimeLimit, saved.Mode.Feed
+	}
+
+	dailyDate := ""
+	if saved != nil {
+		dailyDate = saved.Mode.Daily
+	} else if *daily {
+		dailyDate = time.Now().Format(dailyLayout)
+	}
+
+	ruleset, ok := tetris.FindRuleset(*rules)
+	if !ok {
+		log.Fatalf("unknown ruleset: %s\n", *rules)
+	}
+
+	var pieces *tetris.PieceSet
+	if *piecesPath != "" {
+		var err error
+		if pieces, err = tetris.LoadPieceSet(*piecesPath); err != nil {
+			log.Fatalf("cannot load piece set: %v\n", err)
+		}
+	}
+
+	transform, err := tetris.ParseRenderTransform(*view)
+	if err != nil {
+		log.Fatalf("cannot parse render mode: %v\n", err)
+	}
+
+	if *big && (*modeName == "puzzle" || *fumen != "" && saved == nil) {
+		log.Fatalf("big mode cannot be used with puzzles or fumen fields\n")
+	}
+
+	config := gameConfig{
+		Ruleset:    ruleset,
+		Pieces:     pieces,
+		PiecesPath: *piecesPath,
+		StartLevel: *startLevel,
+		Mode:       nil,
+		Practice:   *practice,
+		Transform:  transform,
+		Big:        *big,
+		Cascade:    *cascade,
+	}
+
 	game := termloop.NewGame()
 	level := termloop.NewBaseLevel(termloop.Cell{})
-	boardEntity := NewBoardPlayer(0, 0)
+	if *modeName == "puzzle" {
+		puzzles, err := tetris.LoadPuzzles(*puzzlesPath)
+		if err != nil {
+			log.Fatalf("cannot load puzzles: %v\n", err)
+		}
+		if len(puzzles) == 0 {
+			log.Fatalf("no puzzles in %s\n", *puzzlesPath)
+		}
+		screen := newPuzzleScreen(puzzles, config)
+		level.AddEntity(screen)
+		game.Screen().SetLevel(level)
+		game.Start()
+		screen.Stop()
+		return
+	}
+
+	mode, err := newGameMode(modeSettings{Name: *modeName, Lines: *lines, TimeLimit: *timeLimit, Feed: *feed, Daily: dailyDate}, config, *scoresPath)
+	if err != nil {
+		log.Fatalf("cannot start game mode: %v\n", err)
+	}
+	config.Mode = mode
+	boardEntity := NewBoardPlayer(0, 0, config, saved)
+	if *fumen != "" && saved == nil {
+		if err := boardEntity.importFumen(*fumen); err != nil {
+			log.Fatalf("cannot import fumen: %v\n", err)
+		}
+	}
 	level.AddEntity(boardEntity)
 	game.Screen().SetLevel(level)
 	game.Start()
+
+	boardEntity.Stop()
+	if *exportPath != "" {
+		if err := boardEntity.exportFumen(*exportPath); err != nil {
+			log.Printf("cannot export fumen: %v\n", err)
+		}
+	}
+	if boardEntity.board.GetState().IsOver {
+		if err := os.Remove(*savePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("cannot remove save file: %v\n", err)
+		}
+		return
+	}
+	if err := saveGame(*savePath, boardEntity.Save()); err != nil {
+		log.Fatalf("cannot save game: %v\n", err)
+	}
+}
+
+type gameConfig struct {
+	Ruleset    tetris.Ruleset
+	Pieces     *tetris.PieceSet
+	PiecesPath string
+	StartLevel int
+	Mode       gameMode
+	Practice   bool
+	Transform  tetris.RenderTransform
+	Big        bool
+	Cascade    bool
 }
 
 type boardPlayer struct {
 	board               *tetris.Board
 	x, y, width, height int
-	score               int
+	ruleset             tetris.Ruleset
+	pieces              *tetris.PieceSet
+	piecesPath          string
+	mode                gameMode
+	startLevel          int
+	big                 bool
+	cascade             bool
+
+	stop     chan struct{}
+	recorded *sync.Once
+	shift    *autoShift
+	history  *history
 
 	scoreText *termloop.Text
+	timeText  *termloop.Text
+	comboText *termloop.Text
+	levelText *termloop.Text
+	modeText  *termloop.Text
 }
 
-func NewBoardPlayer(x, y int) *boardPlayer {
+func NewBoardPlayer(x, y int, config gameConfig, saved *savedGame) *boardPlayer {
 	b := &boardPlayer{
-		width:  10,
-		height: 24,
-		x:      x,
-		y:      y,
-		score:  0,
+		width:      10,
+		height:     24,
+		x:          x,
+		y:          y,
+		ruleset:    config.Ruleset,
+		pieces:     config.Pieces,
+		piecesPath: config.PiecesPath,
+		mode:       config.Mode,
+		startLevel: config.StartLevel,
+		big:        config.Big,
+		cascade:    config.Cascade,
+
+		stop:     make(chan struct{}),
+		recorded: &sync.Once{},
+		shift:    newAutoShift(config.Ruleset.DAS, config.Ruleset.ARR),
 
 		scoreText: termloop.NewText(x+10+3, y+8, "0", termloop.ColorWhite, termloop.ColorDefault),
+		timeText:  termloop.NewText(x+10+3, y+9, "0", termloop.ColorWhite, termloop.ColorDefault),
+		comboText: termloop.NewText(x+10+3, y+10, "", termloop.ColorWhite, termloop.ColorDefault),
+		levelText: termloop.NewText(x+10+3, y+11, "", termloop.ColorWhite, termloop.ColorDefault),
+		modeText:  termloop.NewText(x+10+3, y+12, "", termloop.ColorWhite, termloop.ColorDefault),
 	}
 
-	b.board = tetris.NewBoard(
+	options := []tetris.BoardOption{
+		tetris.WithRuleset(config.Ruleset),
 		tetris.WithSize(10, 24),
-		tetris.WithCompleteHandler(tetris.CompleteHandlerFunc(func(rows int) {
-			b.score += rows * (rows + 1)
+		tetris.WithHiddenRows(2),
+		tetris.WithClearHandler(tetris.ClearHandlerFunc(func(event tetris.ClearEvent) {
+			b.mode.OnClear(event, b.board.GetState())
+			if b.history != nil && event.Chain == 0 {
+				b.history.push(b.Save())
+			}
 		})),
-	)
+	}
+	if config.StartLevel >= 0 {
+		options = append(options, tetris.WithStartLevel(config.StartLevel))
+	}
+	if config.Pieces != nil {
+		options = append(options, tetris.WithPieceSet(config.Pieces))
+	}
+	if config.Big {
+		options = append(options, tetris.WithBigMode())
+	}
+	if config.Cascade {
+		options = append(options, tetris.WithCascade())
+	}
+	if config.Transform != nil {
+		options = append(options, tetris.WithRenderTransform(config.Transform))
+	}
+	options = append(options, config.Mode.Options()...)
+	b.board = tetris.NewBoard(options...)
+
+	if saved != nil {
+		b.board.SetState(saved.State)
+		b.restoreProgress(saved.Progress)
+	}
+
+	if config.Practice {
+		b.history = newHistory(b.Save())
+	}
 
-	ticker := time.NewTicker(500 * time.Millisecond)
 	go func() {
-		for range ticker.C {
-			b.board.Apply(tetris.ActionTick)
+		for {
+			timer := time.NewTimer(b.board.TickDelay())
+			select {
+			case <-timer.C:
+				b.board.Apply(tetris.ActionTick)
+				b.recordIfOver()
+			case <-b.stop:
+				timer.Stop()
+				return
+			}
 		}
 	}()
 
 	return b
 }
 
+func (b *boardPlayer) recordIfOver() {
+	state := b.board.GetState()
+	if !state.IsOver {
+		return
+	}
+	if recorder, ok := b.mode.(scoreRecorder); ok {
+		b.recorded.Do(func() {
+			recorder.Record(state)
+		})
+	}
+}
+
+func (b *boardPlayer) Stop() {
+	close(b.stop)
+}
+
+func (b *boardPlayer) Save() *savedGame {
+	game := &savedGame{
+		State:      b.board.GetState(),
+		Ruleset:    b.ruleset.Name,
+		Pieces:     b.piecesPath,
+		Mode:       b.mode.Settings(),
+		StartLevel: b.startLevel,
+		Big:        b.big,
+		Cascade:    b.cascade,
+	}
+	if keeper, ok := b.mode.(progressKeeper); ok {
+		game.Progress = keeper.Progress()
+	}
+	return game
+}
+
+func (b *boardPlayer) restoreProgress(progress modeProgress) {
+	if keeper, ok := b.mode.(progressKeeper); ok {
+		keeper.Restore(progress)
+	}
+}
+
+func (b *boardPlayer) restore(snapshot *savedGame) {
+	state := snapshot.State
+	state.Elapsed = b.board.Elapsed()
+	b.board.SetState(state)
+	b.restoreProgress(snapshot.Progress)
+}
+
 func (b *boardPlayer) Tick(ev termloop.Event) {
+	if ev.Type == termloop.EventKey && b.history != nil {
+		switch ev.Ch {
+		case 'z':
+			if snapshot, ok := b.history.undo(); ok {
+				b.restore(snapshot)
+			}
+		case 'y':
+			if snapshot, ok := b.history.redo(); ok {
+				b.restore(snapshot)
+			}
+		}
+	}
+
 	if ev.Type == termloop.EventKey {
+		if ev.Key != termloop.KeyArrowLeft && ev.Key != termloop.KeyArrowRight {
+			b.shift.release()
+		}
+
+		switch ev.Ch {
+		case 'x':
+			b.board.Apply(tetris.ActionRotateClockwise)
+		case 'c':
+			b.board.Apply(tetris.ActionHold)
+		}
+
 		switch ev.Key {
 		case termloop.KeyArrowLeft:
-			b.board.Apply(tetris.ActionGoLeft)
+			if ok, repeat := b.shift.allow(ev.Key, time.Now()); ok && repeat {
+				b.board.Apply(tetris.ActionShiftLeft)
+			} else if ok {
+				b.board.Apply(tetris.ActionGoLeft)
+			}
 		case termloop.KeyArrowRight:
-			b.board.Apply(tetris.ActionGoRight)
+			if ok, repeat := b.shift.allow(ev.Key, time.Now()); ok && repeat {
+				b.board.Apply(tetris.ActionShiftRight)
+			} else if ok {
+				b.board.Apply(tetris.ActionGoRight)
+			}
 		case termloop.KeyArrowUp:
 			b.board.Apply(tetris.ActionRotate)
 		case termloop.KeyArrowDown:
 			b.board.Apply(tetris.ActionSmash)
-		case termloop.KeySpace:
-			b.board.Apply(tetris.ActionFill)
 		}
 	}
 }
@@ -96,47 +372,33 @@ func (b *boardPlayer) Draw(s *termloop.Screen) {
 		})
 	}
 
-	for i := 0; i < 6; i++ {
-		s.RenderCell(b.x+b.width+3+i, b.y, &termloop.Cell{
-			Fg: termloop.ColorWhite,
-			Bg: termloop.ColorBlack,
-			Ch: '+',
-		})
-		s.RenderCell(b.x+b.width+3+i, b.y+5, &termloop.Cell{
-			Fg: termloop.ColorWhite,
-			Bg: termloop.ColorBlack,
-			Ch: '+',
-		})
-		s.RenderCell(b.x+b.width+3, b.y+i, &termloop.Cell{
-			Fg: termloop.ColorWhite,
-			Bg: termloop.ColorBlack,
-			Ch: '+',
-		})
-		s.RenderCell(b.x+b.width+8, b.y+i, &termloop.Cell{
-			Fg: termloop.ColorWhite,
-			Bg: termloop.ColorBlack,
-			Ch: '+',
-		})
+	termui.DrawBox(s, b.x+b.width+3, b.y)
+	if b.ruleset.Hold {
+		termui.DrawBox(s, b.x+b.width+3, b.y+13)
 	}
 
-	b.scoreText.SetText(fmt.Sprintf("Score: %d", b.score))
+	state := b.board.GetState()
+	b.scoreText.SetText(fmt.Sprintf("Score: %d", state.Score))
 	b.scoreText.Draw(s)
-
-	next := b.board.Next()
-	for y := 0; y < 4; y++ {
-		for x := 0; x < 4; x++ {
-			ch := rune(0)
-			if next[y][x] == 1 {
-				ch = '@'
-			}
-			s.RenderCell(b.x+b.width+4+x, b.y+1+y, &termloop.Cell{
-				Fg: termloop.ColorWhite,
-				Bg: termloop.ColorBlack,
-				Ch: ch,
-			})
+	b.comboText.SetText(state.ComboStatus())
+	b.comboText.Draw(s)
+	b.timeText.SetText(fmt.Sprintf("Time: %s", formatDuration(state.Elapsed)))
+	b.timeText.Draw(s)
+	b.levelText.SetText(fmt.Sprintf("Level: %d Lines: %d", state.Level, state.Lines))
+	b.levelText.Draw(s)
+	b.modeText.SetText(b.mode.Status(state))
+	b.modeText.Draw(s)
+	if state.IsOver {
+		for i, line := range b.mode.Results(state) {
+			termloop.NewText(b.x+b.width+3, b.y+20+i, line, termloop.ColorWhite, termloop.ColorDefault).Draw(s)
 		}
 	}
 
+	termui.DrawTetromino(s, b.x+b.width+4, b.y+1, state.Next, b.pieceColor(state.Next))
+	if b.ruleset.Hold {
+		termui.DrawTetromino(s, b.x+b.width+4, b.y+14, state.Hold, b.pieceColor(state.Hold))
+	}
+
 	tiles := b.board.Render()
 	for y := 0; y < b.height; y++ {
 		for x := 0; x < b.width; x++ {
@@ -146,7 +408,7 @@ func (b *boardPlayer) Draw(s *termloop.Screen) {
 
 			switch tiles[y][x] {
 			case tetris.TileTetromino:
-				fg = termloop.ColorWhite
+				fg = b.pieceColor(state.Current)
 				bg = termloop.ColorBlack
 				ch = '@'
 			case tetris.TileNormalBlock:
@@ -0,0 +1,504 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jauhararifin/tetris"
+)
+
+type modeSettings struct {
+	Name      string
+	Lines     int
+	TimeLimit time.Duration
+	Feed      int
+	Daily     string
+}
+
+type gameMode interface {
+	Settings() modeSettings
+	Options() []tetris.BoardOption
+	OnClear(event tetris.ClearEvent, state tetris.State)
+	Status(state tetris.State) string
+	Results(state tetris.State) []string
+}
+
+type scoreRecorder interface {
+	Record(state tetris.State)
+}
+
+type modeProgress struct {
+	Pieces int
+	Faults int
+	Splits []time.Duration
+	Clears map[string]int
+	Scores map[string]int
+}
+
+type progressKeeper interface {
+	Progress() modeProgress
+	Restore(progress modeProgress)
+}
+
+func newGameMode(settings modeSettings, config gameConfig, scoresPath string) (gameMode, error) {
+	if settings.Daily != "" {
+		daily := settings.Daily
+		settings.Daily = ""
+		mode, err := newGameMode(settings, config, scoresPath)
+		if err != nil {
+			return nil, err
+		}
+		return newDailyMode(mode, daily, dailyVariant(config), scoresPath)
+	}
+
+	ruleset, startLevel := config.Ruleset, config.StartLevel
+
+	switch settings.Name {
+	case "", "endless":
+		return endlessMode{}, nil
+	case "sprint":
+		if settings.Lines <= 0 {
+			settings.Lines = 40
+		}
+		return newSprintMode(settings), nil
+	case "ultra":
+		if settings.TimeLimit <= 0 {
+			settings.TimeLimit = 2 * time.Minute
+		}
+		return newUltraMode(settings), nil
+	case "marathon":
+		if settings.Lines <= 0 {
+			settings.Lines = 150
+		}
+		if startLevel < 0 {
+			startLevel = ruleset.StartLevel
+		}
+		if !levelsUpEveryTenLines(ruleset.Scoring, startLevel, settings.Lines) {
+			return nil, fmt.Errorf("marathon mode levels up every 10 lines, the %s ruleset does not from level %d", ruleset.Name, startLevel)
+		}
+		return newMarathonMode(settings, ruleset.Scoring, startLevel), nil
+	case "dig":
+		if settings.Lines <= 0 {
+			settings.Lines = 10
+		}
+		return newDigMode(settings), nil
+	case "survival":
+		return newSurvivalMode(settings, scoresPath), nil
+	}
+	return nil, fmt.Errorf("unknown game mode: %s", settings.Name)
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Truncate(time.Millisecond)
+	return fmt.Sprintf("%02d:%02d.%03d", int(d.Minutes()), int(d.Seconds())%60, d.Milliseconds()%1000)
+}
+
+func piecesPerSecond(pieces int, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(pieces) / elapsed.Seconds()
+}
+
+type endlessMode struct{}
+
+func (endlessMode) Settings() modeSettings {
+	return modeSettings{Name: "endless"}
+}
+
+func (endlessMode) Options() []tetris.BoardOption {
+	return nil
+}
+
+func (endlessMode) OnClear(event tetris.ClearEvent, state tetris.State) {}
+
+func (endlessMode) Status(state tetris.State) string {
+	return ""
+}
+
+func (endlessMode) Results(state tetris.State) []string {
+	return []string{"Game over", fmt.Sprintf("Final score: %d", state.Score)}
+}
+
+type sprintMode struct {
+	m        *sync.Mutex
+	settings modeSettings
+	pieces   int
+	faults   int
+	splits   []time.Duration
+}
+
+func newSprintMode(settings modeSettings) *sprintMode {
+	return &sprintMode{
+		m:        &sync.Mutex{},
+		settings: settings,
+		pieces:   0,
+		faults:   0,
+		splits:   nil,
+	}
+}
+
+func (s *sprintMode) Settings() modeSettings {
+	return s.settings
+}
+
+func (s *sprintMode) Options() []tetris.BoardOption {
+	return []tetris.BoardOption{tetris.WithLineGoal(s.settings.Lines)}
+}
+
+func (s *sprintMode) OnClear(event tetris.ClearEvent, state tetris.State) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if event.Chain == 0 {
+		s.pieces++
+	}
+	s.faults += event.Finesse
+	for len(s.splits) < minInt(state.Lines, s.settings.Lines)/10 {
+		s.splits = append(s.splits, state.Elapsed)
+	}
+}
+
+func (s *sprintMode) Progress() modeProgress {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return modeProgress{Pieces: s.pieces, Faults: s.faults, Splits: append([]time.Duration(nil), s.splits...)}
+}
+
+func (s *sprintMode) Restore(progress modeProgress) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.pieces, s.faults = progress.Pieces, progress.Faults
+	s.splits = append([]time.Duration(nil), progress.Splits...)
+}
+
+func (s *sprintMode) Status(state tetris.State) string {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	status := fmt.Sprintf("Lines left: %d", maxInt(s.settings.Lines-state.Lines, 0))
+	if len(s.splits) > 0 {
+		status += fmt.Sprintf(" Split: %s", formatDuration(s.splits[len(s.splits)-1]))
+	}
+	return status
+}
+
+func (s *sprintMode) Results(state tetris.State) []string {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	title := fmt.Sprintf("Sprint %d complete!", s.settings.Lines)
+	if !state.IsCompleted {
+		title = fmt.Sprintf("Sprint %d failed", s.settings.Lines)
+	}
+	results := []string{
+		title,
+		fmt.Sprintf("Time: %s", formatDuration(state.Elapsed)),
+		fmt.Sprintf("Pieces: %d (%.2f PPS)", s.pieces, piecesPerSecond(s.pieces, state.Elapsed)),
+		fmt.Sprintf("Finesse faults: %d", s.faults),
+	}
+	for i, split := range s.splits {
+		results = append(results, fmt.Sprintf("%3d lines: %s", (i+1)*10, formatDuration(split)))
+	}
+	return results
+}
+
+type ultraMode struct {
+	m        *sync.Mutex
+	settings modeSettings
+	pieces   int
+	clears   map[string]int
+	scores   map[string]int
+}
+
+func newUltraMode(settings modeSettings) *ultraMode {
+	return &ultraMode{
+		m:        &sync.Mutex{},
+		settings: settings,
+		pieces:   0,
+		clears:   make(map[string]int),
+		scores:   make(map[string]int),
+	}
+}
+
+func (u *ultraMode) Settings() modeSettings {
+	return u.settings
+}
+
+func (u *ultraMode) Options() []tetris.BoardOption {
+	return []tetris.BoardOption{tetris.WithTimeLimit(u.settings.TimeLimit)}
+}
+
+func (u *ultraMode) OnClear(event tetris.ClearEvent, state tetris.State) {
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	if event.Chain == 0 {
+		u.pieces++
+	}
+	if event.Rows > 0 || event.TSpin {
+		kind := clearKind(event)
+		u.clears[kind]++
+		u.scores[kind] += event.Score
+	}
+}
+
+func (u *ultraMode) Progress() modeProgress {
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	return modeProgress{Pieces: u.pieces, Clears: copyCounts(u.clears), Scores: copyCounts(u.scores)}
+}
+
+func (u *ultraMode) Restore(progress modeProgress) {
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	u.pieces = progress.Pieces
+	u.clears, u.scores = copyCounts(progress.Clears), copyCounts(progress.Scores)
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	result := make(map[string]int, len(counts))
+	for key, count := range counts {
+		result[key] = count
+	}
+	return result
+}
+
+func (u *ultraMode) Status(state tetris.State) string {
+	return fmt.Sprintf("Time left: %s", formatDuration(u.settings.TimeLimit-state.Elapsed))
+}
+
+func (u *ultraMode) Results(state tetris.State) []string {
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	title := fmt.Sprintf("Ultra %s finished!", u.settings.TimeLimit)
+	if !state.IsCompleted {
+		title = fmt.Sprintf("Ultra %s topped out", u.settings.TimeLimit)
+	}
+	perPiece := 0.0
+	if u.pieces > 0 {
+		perPiece = float64(state.Score) / float64(u.pieces)
+	}
+	results := []string{
+		title,
+		fmt.Sprintf("Score: %d Lines: %d", state.Score, state.Lines),
+		fmt.Sprintf("Pieces: %d (%.1f points/piece)", u.pieces, perPiece),
+	}
+	for _, kind := range clearKinds {
+		if u.clears[kind] > 0 {
+			results = append(results, fmt.Sprintf("%s: %d x, %d points", kind, u.clears[kind], u.scores[kind]))
+		}
+	}
+	return results
+}
+
+type marathonMode struct {
+	settings   modeSettings
+	scoring    tetris.Scoring
+	startLevel int
+}
+
+func levelsUpEveryTenLines(scoring tetris.Scoring, startLevel, lines int) bool {
+	for n := 0; n <= lines; n++ {
+		if scoring.Level(startLevel, n) != startLevel+n/10 {
+			return false
+		}
+	}
+	return true
+}
+
+func newMarathonMode(settings modeSettings, scoring tetris.Scoring, startLevel int) *marathonMode {
+	return &marathonMode{settings: settings, scoring: scoring, startLevel: startLevel}
+}
+
+func (m *marathonMode) Settings() modeSettings {
+	return m.settings
+}
+
+func (m *marathonMode) Options() []tetris.BoardOption {
+	return []tetris.BoardOption{tetris.WithLineGoal(m.settings.Lines)}
+}
+
+func (m *marathonMode) OnClear(event tetris.ClearEvent, state tetris.State) {}
+
+func (m *marathonMode) Status(state tetris.State) string {
+	status := fmt.Sprintf("Lines left: %d", maxInt(m.settings.Lines-state.Lines, 0))
+	for lines := state.Lines + 1; lines < m.settings.Lines; lines++ {
+		if m.scoring.Level(m.startLevel, lines) > state.Level {
+			return status + fmt.Sprintf(" Next level: %d", lines-state.Lines)
+		}
+	}
+	return status
+}
+
+func (m *marathonMode) Results(state tetris.State) []string {
+	title := fmt.Sprintf("Marathon %d complete!", m.settings.Lines)
+	if !state.IsCompleted {
+		title = fmt.Sprintf("Marathon %d over", m.settings.Lines)
+	}
+	return []string{
+		title,
+		fmt.Sprintf("Score: %d", state.Score),
+		fmt.Sprintf("Lines: %d Level: %d", state.Lines, state.Level),
+		fmt.Sprintf("Time: %s", formatDuration(state.Elapsed)),
+	}
+}
+
+type digMode struct {
+	m        *sync.Mutex
+	settings modeSettings
+	pieces   int
+}
+
+func newDigMode(settings modeSettings) *digMode {
+	return &digMode{m: &sync.Mutex{}, settings: settings, pieces: 0}
+}
+
+func (d *digMode) Settings() modeSettings {
+	return d.settings
+}
+
+func (d *digMode) Options() []tetris.BoardOption {
+	return []tetris.BoardOption{tetris.WithDig(d.settings.Lines, d.settings.Feed)}
+}
+
+func (d *digMode) OnClear(event tetris.ClearEvent, state tetris.State) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if event.Chain == 0 {
+		d.pieces++
+	}
+}
+
+func (d *digMode) Progress() modeProgress {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	return modeProgress{Pieces: d.pieces}
+}
+
+func (d *digMode) Restore(progress modeProgress) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	d.pieces = progress.Pieces
+}
+
+func (d *digMode) Status(state tetris.State) string {
+	return fmt.Sprintf("Garbage left: %d", state.DigRemaining)
+}
+
+func (d *digMode) Results(state tetris.State) []string {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	title := fmt.Sprintf("Dig %d complete!", d.settings.Lines)
+	if !state.IsCompleted {
+		title = fmt.Sprintf("Dig %d failed", d.settings.Lines)
+	}
+	return []string{
+		title,
+		fmt.Sprintf("Dug: %d/%d", d.settings.Lines-state.DigRemaining, d.settings.Lines),
+		fmt.Sprintf("Time: %s", formatDuration(state.Elapsed)),
+		fmt.Sprintf("Pieces: %d (%.2f PPS)", d.pieces, piecesPerSecond(d.pieces, state.Elapsed)),
+	}
+}
+
+type survivalMode struct {
+	m           *sync.Mutex
+	settings    modeSettings
+	scoresPath  string
+	leaderboard []string
+}
+
+func newSurvivalMode(settings modeSettings, scoresPath string) *survivalMode {
+	return &survivalMode{m: &sync.Mutex{}, settings: settings, scoresPath: scoresPath, leaderboard: nil}
+}
+
+func (s *survivalMode) Settings() modeSettings {
+	return s.settings
+}
+
+func (s *survivalMode) Options() []tetris.BoardOption {
+	return []tetris.BoardOption{tetris.WithRisingGarbage(tetris.SurvivalInterval)}
+}
+
+func (s *survivalMode) OnClear(event tetris.ClearEvent, state tetris.State) {}
+
+func (s *survivalMode) Status(state tetris.State) string {
+	var next time.Duration
+	for i := 0; i <= state.GarbageRises; i++ {
+		next += tetris.SurvivalInterval(i)
+	}
+	return fmt.Sprintf("Rows raised: %d Next: %s", state.GarbageRises, formatDuration(next-state.Elapsed))
+}
+
+func (s *survivalMode) Results(state tetris.State) []string {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	results := []string{
+		fmt.Sprintf("Survived %s", formatDuration(state.Elapsed)),
+		fmt.Sprintf("Rows raised: %d Lines: %d", state.GarbageRises, state.Lines),
+	}
+	return append(results, s.leaderboard...)
+}
+
+func (s *survivalMode) Record(state tetris.State) {
+	entry := scoreEntry{Score: state.Score, Lines: state.Lines, Time: state.Elapsed, Date: time.Now()}
+	entries, rank, err := recordScore(s.scoresPath, "survival", entry, func(a, b scoreEntry) bool {
+		return a.Time > b.Time
+	})
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if err != nil {
+		s.leaderboard = []string{fmt.Sprintf("Cannot record time: %v", err)}
+		return
+	}
+	s.leaderboard = []string{"Best times:"}
+	for i, entry := range entries {
+		marker := " "
+		if i == rank {
+			marker = "*"
+		}
+		s.leaderboard = append(s.leaderboard, fmt.Sprintf("%s%2d. %s %s", marker, i+1, formatDuration(entry.Time), entry.Date.Format("2006-01-02")))
+	}
+}
+
+var clearKinds = []string{"Single", "Double", "Triple", "Tetris", "T-spin", "T-spin single", "T-spin double", "T-spin triple", "Perfect clear"}
+
+func clearKind(event tetris.ClearEvent) string {
+	switch {
+	case event.PerfectClear:
+		return "Perfect clear"
+	case event.TSpin && event.Rows == 0:
+		return "T-spin"
+	case event.TSpin:
+		return "T-spin " + strings.ToLower(clearKinds[minInt(event.Rows, 3)-1])
+	}
+	return clearKinds[minInt(event.Rows, 4)-1]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/JoelOtter/termloop"
+	"github.com/jauhararifin/tetris"
+)
+
+var pieceColors = map[string]termloop.Attr{
+	"black":   termloop.ColorBlack,
+	"red":     termloop.ColorRed,
+	"green":   termloop.ColorGreen,
+	"yellow":  termloop.ColorYellow,
+	"blue":    termloop.ColorBlue,
+	"magenta": termloop.ColorMagenta,
+	"cyan":    termloop.ColorCyan,
+	"white":   termloop.ColorWhite,
+}
+
+func (b *boardPlayer) pieceColor(t tetris.Tetromino) termloop.Attr {
+	if b.pieces == nil {
+		return termloop.ColorWhite
+	}
+	if color, ok := pieceColors[b.pieces.Color(t)]; ok {
+		return color
+	}
+	return termloop.ColorWhite
+}
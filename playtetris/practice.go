@@ -0,0 +1,54 @@
+package main
+
+import "sync"
+
+type history struct {
+	m         *sync.Mutex
+	snapshots []*savedGame
+	cursor    int
+}
+
+func newHistory(initial *savedGame) *history {
+	return &history{
+		m:         &sync.Mutex{},
+		snapshots: []*savedGame{initial},
+		cursor:    0,
+	}
+}
+
+func (h *history) push(snapshot *savedGame) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	h.snapshots = append(h.snapshots[:h.cursor+1], snapshot)
+	h.cursor++
+}
+
+func (h *history) undo() (*savedGame, bool) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	if h.cursor == 0 {
+		return nil, false
+	}
+	h.cursor--
+	return h.snapshots[h.cursor], true
+}
+
+func (h *history) redo() (*savedGame, bool) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	if h.cursor == len(h.snapshots)-1 {
+		return nil, false
+	}
+	h.cursor++
+	return h.snapshots[h.cursor], true
+}
+
+func (h *history) played() []*savedGame {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	return append([]*savedGame{}, h.snapshots[:h.cursor+1]...)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/JoelOtter/termloop"
+	"github.com/jauhararifin/tetris"
+)
+
+type puzzleMode struct {
+	puzzle tetris.Puzzle
+}
+
+func newPuzzleMode(puzzle tetris.Puzzle) *puzzleMode {
+	return &puzzleMode{puzzle: puzzle}
+}
+
+func (p *puzzleMode) Settings() modeSettings {
+	return modeSettings{Name: "puzzle"}
+}
+
+func (p *puzzleMode) Options() []tetris.BoardOption {
+	return []tetris.BoardOption{tetris.WithPuzzle(p.puzzle)}
+}
+
+func (p *puzzleMode) OnClear(event tetris.ClearEvent, state tetris.State) {}
+
+func (p *puzzleMode) Status(state tetris.State) string {
+	left := 0
+	if state.Getter != nil {
+		left = len(state.Getter.Queue)
+	}
+	if state.Next != (tetris.Tetromino{}) {
+		left++
+	}
+	return fmt.Sprintf("%s Pieces left: %d", puzzleGoalText(p.puzzle), left)
+}
+
+func (p *puzzleMode) Results(state tetris.State) []string {
+	title := fmt.Sprintf("%s solved!", p.puzzle.Name)
+	if !state.IsCompleted {
+		title = fmt.Sprintf("%s failed", p.puzzle.Name)
+	}
+	return []string{title, "Press Enter to choose another puzzle"}
+}
+
+func puzzleGoalText(puzzle tetris.Puzzle) string {
+	switch puzzle.Goal {
+	case tetris.PuzzleLines:
+		return fmt.Sprintf("Clear %d lines.", puzzle.Lines)
+	case tetris.PuzzleTSpinDouble:
+		return "Get a T-spin double."
+	case tetris.PuzzlePerfectClear:
+		return "Get a perfect clear."
+	}
+	return "Clear every block."
+}
+
+type puzzleScreen struct {
+	puzzles  []tetris.Puzzle
+	selected int
+	config   gameConfig
+	player   *boardPlayer
+}
+
+func newPuzzleScreen(puzzles []tetris.Puzzle, config gameConfig) *puzzleScreen {
+	return &puzzleScreen{puzzles: puzzles, selected: 0, config: config, player: nil}
+}
+
+func (p *puzzleScreen) Stop() {
+	if p.player != nil {
+		p.player.Stop()
+		p.player = nil
+	}
+}
+
+func (p *puzzleScreen) Tick(ev termloop.Event) {
+	if p.player != nil {
+		if ev.Type == termloop.EventKey && ev.Key == termloop.KeyEnter && p.player.board.GetState().IsOver {
+			p.Stop()
+			return
+		}
+		p.player.Tick(ev)
+		return
+	}
+
+	if ev.Type != termloop.EventKey {
+		return
+	}
+	switch ev.Key {
+	case termloop.KeyArrowUp:
+		p.selected = (p.selected + len(p.puzzles) - 1) % len(p.puzzles)
+	case termloop.KeyArrowDown:
+		p.selected = (p.selected + 1) % len(p.puzzles)
+	case termloop.KeyEnter:
+		config := p.config
+		config.Mode = newPuzzleMode(p.puzzles[p.selected])
+		p.player = NewBoardPlayer(0, 0, config, nil)
+	}
+}
+
+func (p *puzzleScreen) Draw(s *termloop.Screen) {
+	if p.player != nil {
+		p.player.Draw(s)
+		return
+	}
+
+	termloop.NewText(0, 0, "Choose a puzzle with the arrow keys and press Enter", termloop.ColorWhite, termloop.ColorDefault).Draw(s)
+	for i, puzzle := range p.puzzles {
+		marker := "  "
+		if i == p.selected {
+			marker = "> "
+		}
+		line := fmt.Sprintf("%s%s - %s", marker, puzzle.Name, puzzleGoalText(puzzle))
+		termloop.NewText(0, i+2, line, termloop.ColorWhite, termloop.ColorDefault).Draw(s)
+	}
+}
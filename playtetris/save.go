@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/gob"
+	"os"
+
+	"github.com/jauhararifin/tetris"
+)
+
+type savedGame struct {
+	State      tetris.State
+	Ruleset    string
+	Pieces     string
+	Mode       modeSettings
+	Progress   modeProgress
+	StartLevel int
+	Big        bool
+	Cascade    bool
+}
+
+func loadGame(path string) (*savedGame, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	game := &savedGame{}
+	if err := gob.NewDecoder(f).Decode(game); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+func saveGame(path string, game *savedGame) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return gob.NewEncoder(f).Encode(game)
+}
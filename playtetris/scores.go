@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/gob"
+	"os"
+	"sort"
+	"time"
+)
+
+const maxScores = 10
+
+type scoreEntry struct {
+	Score     int
+	Lines     int
+	Time      time.Duration
+	Date      time.Time
+	Completed bool
+}
+
+type scoreTable map[string][]scoreEntry
+
+func loadScores(path string) (scoreTable, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return scoreTable{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	table := scoreTable{}
+	if err := gob.NewDecoder(f).Decode(&table); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+func saveScores(path string, table scoreTable) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return gob.NewEncoder(f).Encode(table)
+}
+
+func recordScore(path, name string, entry scoreEntry, better func(a, b scoreEntry) bool) ([]scoreEntry, int, error) {
+	table, err := loadScores(path)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	entries := append(table[name], entry)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return better(entries[i], entries[j])
+	})
+	rank := -1
+	for i := range entries {
+		if entries[i] == entry {
+			rank = i
+			break
+		}
+	}
+	if len(entries) > maxScores {
+		entries = entries[:maxScores]
+	}
+	if rank >= maxScores {
+		rank = -1
+	}
+	table[name] = entries
+
+	return entries, rank, saveScores(path, table)
+}
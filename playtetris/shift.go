@@ -0,0 +1,39 @@
+package main
+
+import (
+	"time"
+
+	"github.com/JoelOtter/termloop"
+)
+
+type autoShift struct {
+	delay, repeat time.Duration
+
+	key       termloop.Key
+	held      bool
+	pressedAt time.Time
+	shiftedAt time.Time
+}
+
+func newAutoShift(delay, repeat time.Duration) *autoShift {
+	return &autoShift{delay: delay, repeat: repeat}
+}
+
+func (a *autoShift) allow(key termloop.Key, now time.Time) (ok, repeat bool) {
+	if !a.held || key != a.key || now.Sub(a.pressedAt) < a.delay {
+		a.key, a.held = key, true
+		a.pressedAt = now
+		a.shiftedAt = now
+		return true, false
+	}
+
+	if now.Sub(a.shiftedAt) < a.repeat {
+		return false, true
+	}
+	a.shiftedAt = now
+	return true, true
+}
+
+func (a *autoShift) release() {
+	a.held = false
+}
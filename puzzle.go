@@ -0,0 +1,199 @@
+package tetris
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type PuzzleGoal int
+
+const (
+	PuzzleClearBlocks PuzzleGoal = iota
+	PuzzleLines
+	PuzzleTSpinDouble
+	PuzzlePerfectClear
+)
+
+var puzzleGoalNames = []string{"clear", "lines", "tspin-double", "perfect-clear"}
+
+func (g PuzzleGoal) String() string {
+	if g < 0 || int(g) >= len(puzzleGoalNames) {
+		return fmt.Sprintf("PuzzleGoal(%d)", int(g))
+	}
+	return puzzleGoalNames[g]
+}
+
+type Puzzle struct {
+	Name   string
+	Goal   PuzzleGoal
+	Lines  int
+	Tiles  []string
+	Pieces []Tetromino
+}
+
+var tetrominoNames = map[string]Tetromino{
+	"I": TetrominoI,
+	"O": TetrominoO,
+	"T": TetrominoT,
+	"S": TetrominoS,
+	"Z": TetrominoZ,
+	"J": TetrominoJ,
+	"L": TetrominoL,
+}
+
+func WithPuzzle(puzzle Puzzle) BoardOption {
+	if len(puzzle.Pieces) == 0 {
+		panic(fmt.Errorf("puzzle %q has no pieces", puzzle.Name))
+	}
+	return func(board *Board) {
+		getter := NewQueueGetter()
+		getter.Push(puzzle.Pieces...)
+		board.tetrominoGetter = getter
+		board.puzzle = &puzzle
+		if puzzle.Goal == PuzzleLines {
+			board.lineGoal = puzzle.Lines
+		}
+	}
+}
+
+func (b *Board) fillPuzzleTiles() {
+	if b.puzzle == nil {
+		return
+	}
+	for i, row := range b.puzzle.Tiles {
+		y := b.height - len(b.puzzle.Tiles) + i
+		for x := 0; x < len(row) && x < b.width && y >= 0; x++ {
+			if row[x] == '#' {
+				b.tiles[y][x] = TileAdditionalBlock
+			}
+		}
+	}
+}
+
+func (b *Board) isPuzzleSolved(event ClearEvent) bool {
+	if b.puzzle == nil {
+		return false
+	}
+	switch b.puzzle.Goal {
+	case PuzzleClearBlocks:
+		return b.countGarbageRows() == 0
+	case PuzzleTSpinDouble:
+		return event.TSpin && event.Rows == 2
+	case PuzzlePerfectClear:
+		return event.PerfectClear
+	}
+	return false
+}
+
+func LoadPuzzles(path string) ([]Puzzle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParsePuzzles(f)
+}
+
+func ParsePuzzles(r io.Reader) ([]Puzzle, error) {
+	puzzles := make([]Puzzle, 0, 0)
+	puzzle := Puzzle{}
+	started := false
+	flush := func(line int) error {
+		if !started {
+			return nil
+		}
+		if puzzle.Name == "" {
+			return fmt.Errorf("puzzle ending at line %d has no name", line)
+		}
+		if len(puzzle.Pieces) == 0 {
+			return fmt.Errorf("puzzle %q has no pieces", puzzle.Name)
+		}
+		puzzles = append(puzzles, puzzle)
+		puzzle, started = Puzzle{}, false
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(text, "//") {
+			continue
+		}
+		if text == "" {
+			if err := flush(line - 1); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		started = true
+		if strings.Trim(text, "#.") == "" {
+			if len(puzzle.Tiles) > 0 && len(text) != len(puzzle.Tiles[0]) {
+				return nil, fmt.Errorf("line %d: board rows must have the same width", line)
+			}
+			puzzle.Tiles = append(puzzle.Tiles, text)
+			continue
+		}
+
+		fields := strings.Fields(text)
+		switch fields[0] {
+		case "puzzle":
+			puzzle.Name = strings.TrimSpace(strings.TrimPrefix(text, "puzzle"))
+		case "goal":
+			if err := parsePuzzleGoal(&puzzle, fields[1:]); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+		case "pieces":
+			for _, name := range fields[1:] {
+				for _, ch := range name {
+					t, ok := tetrominoNames[strings.ToUpper(string(ch))]
+					if !ok {
+						return nil, fmt.Errorf("line %d: unknown piece %q", line, ch)
+					}
+					puzzle.Pieces = append(puzzle.Pieces, t)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unknown puzzle field %q", line, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(line); err != nil {
+		return nil, err
+	}
+	return puzzles, nil
+}
+
+func parsePuzzleGoal(puzzle *Puzzle, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing goal")
+	}
+	for i, name := range puzzleGoalNames {
+		if args[0] != name {
+			continue
+		}
+		puzzle.Goal = PuzzleGoal(i)
+		if puzzle.Goal != PuzzleLines {
+			return nil
+		}
+		if len(args) < 2 {
+			return fmt.Errorf("lines goal needs a line count")
+		}
+		lines, err := strconv.Atoi(args[1])
+		if err != nil || lines <= 0 {
+			return fmt.Errorf("invalid line count %q", args[1])
+		}
+		puzzle.Lines = lines
+		return nil
+	}
+	return fmt.Errorf("unknown goal %q", args[0])
+}
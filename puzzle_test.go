@@ -0,0 +1,126 @@
+package tetris
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePuzzles(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Puzzle
+		err   string
+	}{
+		{
+			name:  "empty",
+			input: "// nothing here\n\n",
+			want:  []Puzzle{},
+		},
+		{
+			name:  "all fields",
+			input: "puzzle Two  words\ngoal lines 2\npieces IoT\n#########.\n#########.\n",
+			want: []Puzzle{{
+				Name:   "Two  words",
+				Goal:   PuzzleLines,
+				Lines:  2,
+				Tiles:  []string{"#########.", "#########."},
+				Pieces: []Tetromino{TetrominoI, TetrominoO, TetrominoT},
+			}},
+		},
+		{
+			name:  "several puzzles",
+			input: "puzzle A\npieces S Z\n\n\npuzzle B\ngoal perfect-clear\npieces J\n// comment\npieces L\n",
+			want: []Puzzle{
+				{Name: "A", Goal: PuzzleClearBlocks, Pieces: []Tetromino{TetrominoS, TetrominoZ}},
+				{Name: "B", Goal: PuzzlePerfectClear, Pieces: []Tetromino{TetrominoJ, TetrominoL}},
+			},
+		},
+		{
+			name:  "missing name",
+			input: "goal clear\npieces I\n",
+			err:   "puzzle ending at line 2 has no name",
+		},
+		{
+			name:  "missing pieces",
+			input: "puzzle A\ngoal clear\n\n",
+			err:   "puzzle \"A\" has no pieces",
+		},
+		{
+			name:  "unknown piece",
+			input: "puzzle A\npieces IX\n",
+			err:   "line 2: unknown piece 'X'",
+		},
+		{
+			name:  "uneven rows",
+			input: "puzzle A\npieces I\n####\n###\n",
+			err:   "line 4: board rows must have the same width",
+		},
+		{
+			name:  "unknown field",
+			input: "puzzle A\nhint use the I\n",
+			err:   "line 2: unknown puzzle field \"hint\"",
+		},
+		{
+			name:  "missing goal",
+			input: "puzzle A\ngoal\n",
+			err:   "line 2: missing goal",
+		},
+		{
+			name:  "unknown goal",
+			input: "puzzle A\ngoal tetris\n",
+			err:   "line 2: unknown goal \"tetris\"",
+		},
+		{
+			name:  "lines goal without count",
+			input: "puzzle A\ngoal lines\n",
+			err:   "line 2: lines goal needs a line count",
+		},
+		{
+			name:  "invalid line count",
+			input: "puzzle A\ngoal lines 0\n",
+			err:   "line 2: invalid line count \"0\"",
+		},
+	}
+
+	for _, test := range tests {
+		puzzles, err := ParsePuzzles(strings.NewReader(test.input))
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: got error %v, want %q", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(puzzles, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, puzzles, test.want)
+		}
+	}
+}
+
+func TestLoadPuzzles(t *testing.T) {
+	puzzles, err := LoadPuzzles("playtetris/puzzles.txt")
+	if err != nil {
+		t.Fatalf("cannot load the example puzzle pack: %v", err)
+	}
+	if len(puzzles) != 4 {
+		t.Fatalf("got %d puzzles, want 4", len(puzzles))
+	}
+
+	goals := []PuzzleGoal{PuzzleLines, PuzzleClearBlocks, PuzzleTSpinDouble, PuzzlePerfectClear}
+	for i, puzzle := range puzzles {
+		if puzzle.Goal != goals[i] {
+			t.Errorf("puzzle %q: got goal %v, want %v", puzzle.Name, puzzle.Goal, goals[i])
+		}
+		for _, row := range puzzle.Tiles {
+			if len(row) != 10 {
+				t.Errorf("puzzle %q: row %q is not 10 wide", puzzle.Name, row)
+			}
+		}
+		NewBoard(WithPuzzle(puzzle))
+	}
+}
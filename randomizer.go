@@ -0,0 +1,206 @@
+package tetris
+
+import "math/rand"
+
+var StandardTetrominos = []Tetromino{
+	TetrominoI,
+	TetrominoJ,
+	TetrominoL,
+	TetrominoO,
+	TetrominoS,
+	TetrominoT,
+	TetrominoZ,
+}
+
+type countingSource struct {
+	seed   int64
+	drawn  int
+	source rand.Source
+}
+
+func (s *countingSource) Int63() int64 {
+	s.drawn++
+	return s.source.Int63()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.seed, s.drawn = seed, 0
+	s.source.Seed(seed)
+}
+
+type countingRand struct {
+	*rand.Rand
+	source *countingSource
+}
+
+func newCountingRand(seed int64) *countingRand {
+	source := &countingSource{seed: seed, drawn: 0, source: rand.NewSource(seed)}
+	return &countingRand{Rand: rand.New(source), source: source}
+}
+
+func (r *countingRand) GetState() GetterState {
+	return GetterState{Seed: r.source.seed, Drawn: r.source.drawn}
+}
+
+func (r *countingRand) SetState(state GetterState) {
+	r.Rand.Seed(state.Seed)
+	for r.source.drawn < state.Drawn {
+		r.source.Int63()
+	}
+}
+
+func tetrominoSet(tetrominos []Tetromino) []Tetromino {
+	if len(tetrominos) == 0 {
+		return StandardTetrominos
+	}
+	return tetrominos
+}
+
+type BagGetter struct {
+	seed       int64
+	drawn      int
+	randomizer *rand.Rand
+	tetrominos []Tetromino
+	bag        []Tetromino
+}
+
+func NewBagGetter(seed int64, tetrominos ...Tetromino) *BagGetter {
+	return &BagGetter{
+		seed:       seed,
+		drawn:      0,
+		randomizer: rand.New(rand.NewSource(seed)),
+		tetrominos: tetrominoSet(tetrominos),
+		bag:        nil,
+	}
+}
+
+func (g *BagGetter) Next() Tetromino {
+	if len(g.bag) == 0 {
+		g.bag = make([]Tetromino, len(g.tetrominos), len(g.tetrominos))
+		copy(g.bag, g.tetrominos)
+		g.randomizer.Shuffle(len(g.bag), func(i, j int) {
+			g.bag[i], g.bag[j] = g.bag[j], g.bag[i]
+		})
+	}
+
+	g.drawn++
+	t := g.bag[0]
+	g.bag = g.bag[1:]
+	return t
+}
+
+func (g *BagGetter) GetState() GetterState {
+	return GetterState{Seed: g.seed, Drawn: g.drawn}
+}
+
+func (g *BagGetter) SetState(state GetterState) {
+	g.seed = state.Seed
+	g.drawn = 0
+	g.randomizer = rand.New(rand.NewSource(state.Seed))
+	g.bag = nil
+	for g.drawn < state.Drawn {
+		g.Next()
+	}
+}
+
+type HistoryGetter struct {
+	seed       int64
+	drawn      int
+	rolls      int
+	randomizer *rand.Rand
+	tetrominos []Tetromino
+	history    []Tetromino
+}
+
+func NewHistoryGetter(seed int64, size, rolls int, tetrominos ...Tetromino) *HistoryGetter {
+	return &HistoryGetter{
+		seed:       seed,
+		drawn:      0,
+		rolls:      rolls,
+		randomizer: rand.New(rand.NewSource(seed)),
+		tetrominos: tetrominoSet(tetrominos),
+		history:    make([]Tetromino, size, size),
+	}
+}
+
+func (g *HistoryGetter) Next() Tetromino {
+	t := g.tetrominos[g.randomizer.Intn(len(g.tetrominos))]
+	for i := 1; i < g.rolls && g.isInHistory(t); i++ {
+		t = g.tetrominos[g.randomizer.Intn(len(g.tetrominos))]
+	}
+
+	g.drawn++
+	if len(g.history) > 0 {
+		g.history = append(g.history[1:], t)
+	}
+	return t
+}
+
+func (g *HistoryGetter) isInHistory(t Tetromino) bool {
+	for _, h := range g.history {
+		if h == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *HistoryGetter) GetState() GetterState {
+	return GetterState{Seed: g.seed, Drawn: g.drawn}
+}
+
+func (g *HistoryGetter) SetState(state GetterState) {
+	g.seed = state.Seed
+	g.drawn = 0
+	g.randomizer = rand.New(rand.NewSource(state.Seed))
+	g.history = make([]Tetromino, len(g.history), len(g.history))
+	for g.drawn < state.Drawn {
+		g.Next()
+	}
+}
+
+type NESGetter struct {
+	seed       int64
+	drawn      int
+	randomizer *rand.Rand
+	tetrominos []Tetromino
+	previous   int
+}
+
+func NewNESGetter(seed int64, tetrominos ...Tetromino) *NESGetter {
+	if len(tetrominos) == 0 {
+		tetrominos = []Tetromino{TetrominoT, TetrominoJ, TetrominoZ, TetrominoO, TetrominoS, TetrominoL, TetrominoI}
+	}
+	return &NESGetter{
+		seed:       seed,
+		drawn:      0,
+		randomizer: rand.New(rand.NewSource(seed)),
+		tetrominos: tetrominos,
+		previous:   -1,
+	}
+}
+
+func (g *NESGetter) Next() Tetromino {
+	i := g.randomizer.Intn(len(g.tetrominos) + 1)
+	if i == len(g.tetrominos) || i == g.previous {
+		i = g.randomizer.Intn(len(g.tetrominos))
+	}
+
+	g.drawn++
+	g.previous = i
+	return g.tetrominos[i]
+}
+
+func (g *NESGetter) GetState() GetterState {
+	return GetterState{Seed: g.seed, Drawn: g.drawn}
+}
+
+func (g *NESGetter) SetState(state GetterState) {
+	g.seed = state.Seed
+	g.drawn = 0
+	g.randomizer = rand.New(rand.NewSource(state.Seed))
+	g.previous = -1
+	for g.drawn < state.Drawn {
+		g.Next()
+	}
+}
@@ -0,0 +1,157 @@
+package tetris
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RenderContext struct {
+	Now         time.Time
+	LockedAt    [][]time.Time
+	HasPiece    bool
+	PieceTop    int
+	PieceBottom int
+}
+
+type RenderTransform interface {
+	Transform(frame [][]Tile, context RenderContext)
+}
+
+type RenderTransformFunc func(frame [][]Tile, context RenderContext)
+
+func (f RenderTransformFunc) Transform(frame [][]Tile, context RenderContext) {
+	f(frame, context)
+}
+
+func WithRenderTransform(transforms ...RenderTransform) BoardOption {
+	return func(board *Board) {
+		board.transforms = append(board.transforms, transforms...)
+	}
+}
+
+func InvisibleTransform(delay time.Duration) RenderTransform {
+	return RenderTransformFunc(func(frame [][]Tile, context RenderContext) {
+		for y := range frame {
+			for x := range frame[y] {
+				if isLockedTile(frame[y][x]) && context.Now.Sub(context.LockedAt[y][x]) >= delay {
+					frame[y][x] = TileEmpty
+				}
+			}
+		}
+	})
+}
+
+func FogTransform(radius int) RenderTransform {
+	return RenderTransformFunc(func(frame [][]Tile, context RenderContext) {
+		for y := range frame {
+			if context.HasPiece && y >= context.PieceTop-radius && y <= context.PieceBottom+radius {
+				continue
+			}
+			for x := range frame[y] {
+				if isLockedTile(frame[y][x]) {
+					frame[y][x] = TileEmpty
+				}
+			}
+		}
+	})
+}
+
+func ParseRenderTransform(spec string) (RenderTransform, error) {
+	name, arg := spec, ""
+	if i := strings.Index(spec, "="); i >= 0 {
+		name, arg = spec[:i], spec[i+1:]
+	}
+
+	switch name {
+	case "", "normal":
+		return nil, nil
+	case "invisible":
+		delay := 3 * time.Second
+		if arg != "" {
+			var err error
+			if delay, err = time.ParseDuration(arg); err != nil || delay < 0 {
+				return nil, fmt.Errorf("invalid invisible delay: %s", arg)
+			}
+		}
+		return InvisibleTransform(delay), nil
+	case "fog":
+		radius := 3
+		if arg != "" {
+			var err error
+			if radius, err = strconv.Atoi(arg); err != nil || radius < 0 {
+				return nil, fmt.Errorf("invalid fog radius: %s", arg)
+			}
+		}
+		return FogTransform(radius), nil
+	}
+	return nil, fmt.Errorf("unknown render mode: %s", name)
+}
+
+func isLockedTile(tile Tile) bool {
+	return tile == TileNormalBlock || tile == TileAdditionalBlock
+}
+
+func (b *Board) lockTimes(tiles [][]Tile) [][]time.Time {
+	now := time.Now()
+	lockedAt := make([][]time.Time, len(tiles), len(tiles))
+	for y := range tiles {
+		lockedAt[y] = make([]time.Time, len(tiles[y]), len(tiles[y]))
+		for x := range tiles[y] {
+			if y < len(b.lockedAt) && x < len(b.lockedAt[y]) && b.tiles[y][x] == tiles[y][x] {
+				lockedAt[y][x] = b.lockedAt[y][x]
+			} else if tiles[y][x] != TileEmpty {
+				lockedAt[y][x] = now
+			}
+		}
+	}
+	return lockedAt
+}
+
+func copyLockTimes(lockedAt [][]time.Time) [][]time.Time {
+	result := make([][]time.Time, len(lockedAt), len(lockedAt))
+	for i := range lockedAt {
+		result[i] = make([]time.Time, len(lockedAt[i]), len(lockedAt[i]))
+		copy(result[i], lockedAt[i])
+	}
+	return result
+}
+
+func sameShape(lockedAt [][]time.Time, tiles [][]Tile) bool {
+	if len(lockedAt) != len(tiles) {
+		return false
+	}
+	for y := range tiles {
+		if len(lockedAt[y]) != len(tiles[y]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *Board) renderContext() RenderContext {
+	context := RenderContext{
+		Now:         time.Now(),
+		LockedAt:    b.lockedAt[b.hiddenRows:],
+		HasPiece:    false,
+		PieceTop:    0,
+		PieceBottom: 0,
+	}
+	for y := 0; y < b.current.Size; y++ {
+		for x := 0; x < b.current.Size; x++ {
+			if !b.current.Block(y, x) {
+				continue
+			}
+			row := b.currentY + y - b.hiddenRows
+			if !context.HasPiece || row < context.PieceTop {
+				context.PieceTop = row
+			}
+			if !context.HasPiece || row > context.PieceBottom {
+				context.PieceBottom = row
+			}
+			context.HasPiece = true
+		}
+	}
+	return context
+}
@@ -0,0 +1,302 @@
+package tetris
+
+type RotationSystem interface {
+	Rotate(t Tetromino, rotation int, clockwise bool) (Tetromino, [][2]int)
+}
+
+type kickRule interface {
+	canKick(rotated Tetromino, blocked func(y, x int) bool) bool
+}
+
+type simpleRotation struct{}
+
+func (simpleRotation) Rotate(t Tetromino, rotation int, clockwise bool) (Tetromino, [][2]int) {
+	if clockwise {
+		return rotateTetrominoTimes(t, 3), [][2]int{{0, 0}}
+	}
+	return rotateTetromino(t), [][2]int{{0, 0}}
+}
+
+type boxRotation struct {
+	kicks func(size, from, to int) [][2]int
+}
+
+func (r boxRotation) Rotate(t Tetromino, rotation int, clockwise bool) (Tetromino, [][2]int) {
+	top, left, bottom, right := tetrominoBounds(t)
+	size := maxInt(bottom-top+1, right-left+1)
+	to := (rotation + 1) % 4
+	if !clockwise {
+		to = (rotation + 3) % 4
+	}
+
+	boxY, boxX := top, left
+	switch {
+	case bottom-top == right-left:
+	case size == 3 && rotation == 1:
+		boxX--
+	case size == 3 && rotation == 2:
+		boxY--
+	case size == 4 && rotation == 0:
+		boxY--
+	case size == 4 && rotation == 1:
+		boxX -= 2
+	case size == 4 && rotation == 2:
+		boxY -= 2
+	case size == 4 && rotation == 3:
+		boxX--
+	}
+
+	cells := tetrominoCells(t)
+	for i, cell := range cells {
+		dy, dx := cell[0]-boxY, cell[1]-boxX
+		cells[i] = [2]int{boxY + dx, boxX + size - 1 - dy}
+		if !clockwise {
+			cells[i] = [2]int{boxY + size - 1 - dx, boxX + dy}
+		}
+	}
+	rotated, shiftY, shiftX := placeCells(t.Size, cells)
+
+	offsets := [][2]int{{0, 0}}
+	if r.kicks != nil {
+		offsets = r.kicks(size, rotation, to)
+	}
+	kicks := make([][2]int, len(offsets), len(offsets))
+	for i, offset := range offsets {
+		kicks[i] = [2]int{offset[0] - shiftX, offset[1] - shiftY}
+	}
+	return rotated, kicks
+}
+
+func tetrominoCells(t Tetromino) [][2]int {
+	cells := make([][2]int, 0, 4)
+	for y := 0; y < t.Size; y++ {
+		for x := 0; x < t.Size; x++ {
+			if t.Block(y, x) {
+				cells = append(cells, [2]int{y, x})
+			}
+		}
+	}
+	return cells
+}
+
+func placeCells(size int, cells [][2]int) (t Tetromino, shiftY, shiftX int) {
+	minY, minX, maxY, maxX := size, size, -1, -1
+	for _, cell := range cells {
+		minY, maxY = minInt(minY, cell[0]), maxInt(maxY, cell[0])
+		minX, maxX = minInt(minX, cell[1]), maxInt(maxX, cell[1])
+	}
+
+	if minY < 0 {
+		shiftY = -minY
+	} else if maxY > size-1 {
+		shiftY = size - 1 - maxY
+	}
+	if minX < 0 {
+		shiftX = -minX
+	} else if maxX > size-1 {
+		shiftX = size - 1 - maxX
+	}
+
+	placed := make([][2]int, len(cells), len(cells))
+	for i, cell := range cells {
+		placed[i] = [2]int{cell[0] + shiftY, cell[1] + shiftX}
+	}
+	return newTetrominoFromCells(size, placed), shiftY, shiftX
+}
+
+func rotateCells(cells [][2]int, centerY, centerX int, clockwise bool) [][2]int {
+	rotated := make([][2]int, len(cells), len(cells))
+	for i, cell := range cells {
+		dy, dx := cell[0]-centerY, cell[1]-centerX
+		rotated[i] = [2]int{centerY + dx, centerX - dy}
+		if !clockwise {
+			rotated[i] = [2]int{centerY - dx, centerX + dy}
+		}
+	}
+	return rotated
+}
+
+type nintendoRotation struct{}
+
+func (nintendoRotation) Rotate(t Tetromino, rotation int, clockwise bool) (Tetromino, [][2]int) {
+	if len(tetrominoCells(t)) != 4 {
+		return boxRotation{}.Rotate(t, rotation, clockwise)
+	}
+
+	top, left, bottom, right := tetrominoBounds(t)
+	height, width := bottom-top+1, right-left+1
+
+	var centerY, centerX int
+	switch {
+	case height == 2 && width == 2:
+		return t, [][2]int{{0, 0}}
+	case height == 1:
+		centerY, centerX, clockwise = top, left+2, true
+	case width == 1:
+		centerY, centerX, clockwise = top+2, left, false
+	default:
+		var ok bool
+		if centerY, centerX, ok = tetrominoLineCenter(t); ok {
+			break
+		}
+		if width == 3 {
+			centerY, centerX, clockwise = top, left+1, false
+		} else {
+			centerY, centerX, clockwise = top+1, left, true
+		}
+	}
+
+	rotated, shiftY, shiftX := placeCells(t.Size, rotateCells(tetrominoCells(t), centerY, centerX, clockwise))
+	return rotated, [][2]int{{-shiftX, -shiftY}}
+}
+
+func tetrominoLineCenter(t Tetromino) (y, x int, ok bool) {
+	for y := 0; y < t.Size; y++ {
+		for x := 0; x < t.Size; x++ {
+			if !t.Block(y, x) {
+				continue
+			}
+			if t.Block(y, x-1) && t.Block(y, x+1) {
+				return y, x, true
+			}
+			if t.Block(y-1, x) && t.Block(y+1, x) {
+				return y, x, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+type arikaRotation struct{}
+
+func (arikaRotation) Rotate(t Tetromino, rotation int, clockwise bool) (Tetromino, [][2]int) {
+	if len(tetrominoCells(t)) != 4 {
+		return boxRotation{}.Rotate(t, rotation, clockwise)
+	}
+
+	top, left, bottom, right := tetrominoBounds(t)
+	height, width := bottom-top+1, right-left+1
+
+	var cells [][2]int
+	switch {
+	case height == 2 && width == 2:
+		return t, [][2]int{{0, 0}}
+	case height == 1:
+		cells = [][2]int{{top - 1, left + 2}, {top, left + 2}, {top + 1, left + 2}, {top + 2, left + 2}}
+	case width == 1:
+		cells = [][2]int{{top + 1, left - 2}, {top + 1, left - 1}, {top + 1, left}, {top + 1, left + 1}}
+	default:
+		boxY, boxX := top, left
+		if height == 2 {
+			boxY--
+		} else if _, centerX, ok := tetrominoLineCenter(t); ok {
+			boxX = centerX - 1
+		} else if t.Block(top, right) {
+			boxX--
+		}
+
+		cells = tetrominoCells(t)
+		for i, cell := range cells {
+			dy, dx := cell[0]-boxY, cell[1]-boxX
+			cells[i] = [2]int{boxY + dx, boxX + 2 - dy}
+			if !clockwise {
+				cells[i] = [2]int{boxY + 2 - dx, boxX + dy}
+			}
+		}
+		cells = alignArikaCells(cells, boxY, boxX)
+	}
+
+	rotated, shiftY, shiftX := placeCells(t.Size, cells)
+	offsets := [][2]int{{0, 0}, {1, 0}, {-1, 0}}
+	if height == 1 || width == 1 {
+		offsets = offsets[:1]
+	}
+	kicks := make([][2]int, len(offsets), len(offsets))
+	for i, offset := range offsets {
+		kicks[i] = [2]int{offset[0] - shiftX, offset[1] - shiftY}
+	}
+	return rotated, kicks
+}
+
+func (arikaRotation) canKick(rotated Tetromino, blocked func(y, x int) bool) bool {
+	cells := tetrominoCells(rotated)
+	top, left, bottom, right := tetrominoBounds(rotated)
+	_, centerX, ok := tetrominoLineCenter(rotated)
+	if len(cells) != 4 || top == bottom || left == right || !ok {
+		return true
+	}
+	for _, cell := range cells {
+		if blocked(cell[0], cell[1]) {
+			return cell[1] != centerX
+		}
+	}
+	return true
+}
+
+func alignArikaCells(cells [][2]int, boxY, boxX int) [][2]int {
+	boxCells := make([][2]int, len(cells), len(cells))
+	for i, cell := range cells {
+		boxCells[i] = [2]int{cell[0] - boxY, cell[1] - boxX}
+	}
+	box := newTetrominoFromCells(3, boxCells)
+
+	top, left, bottom, right := tetrominoBounds(box)
+	shiftY, shiftX := 2-bottom, 0
+	if _, _, ok := tetrominoLineCenter(box); !ok && right-left == 1 {
+		shiftX = -left
+		if box.Block(top, right) {
+			shiftX = 1 - left
+		}
+	}
+
+	for i := range cells {
+		cells[i] = [2]int{cells[i][0] + shiftY, cells[i][1] + shiftX}
+	}
+	return cells
+}
+
+var (
+	srsKicksJLSTZ = map[[2]int][][2]int{
+		{0, 1}: {{0, 0}, {-1, 0}, {-1, 1}, {0, -2}, {-1, -2}},
+		{1, 0}: {{0, 0}, {1, 0}, {1, -1}, {0, 2}, {1, 2}},
+		{1, 2}: {{0, 0}, {1, 0}, {1, -1}, {0, 2}, {1, 2}},
+		{2, 1}: {{0, 0}, {-1, 0}, {-1, 1}, {0, -2}, {-1, -2}},
+		{2, 3}: {{0, 0}, {1, 0}, {1, 1}, {0, -2}, {1, -2}},
+		{3, 2}: {{0, 0}, {-1, 0}, {-1, -1}, {0, 2}, {-1, 2}},
+		{3, 0}: {{0, 0}, {-1, 0}, {-1, -1}, {0, 2}, {-1, 2}},
+		{0, 3}: {{0, 0}, {1, 0}, {1, 1}, {0, -2}, {1, -2}},
+	}
+	srsKicksI = map[[2]int][][2]int{
+		{0, 1}: {{0, 0}, {-2, 0}, {1, 0}, {-2, -1}, {1, 2}},
+		{1, 0}: {{0, 0}, {2, 0}, {-1, 0}, {2, 1}, {-1, -2}},
+		{1, 2}: {{0, 0}, {-1, 0}, {2, 0}, {-1, 2}, {2, -1}},
+		{2, 1}: {{0, 0}, {1, 0}, {-2, 0}, {1, -2}, {-2, 1}},
+		{2, 3}: {{0, 0}, {2, 0}, {-1, 0}, {2, 1}, {-1, -2}},
+		{3, 2}: {{0, 0}, {-2, 0}, {1, 0}, {-2, -1}, {1, 2}},
+		{3, 0}: {{0, 0}, {1, 0}, {-2, 0}, {1, -2}, {-2, 1}},
+		{0, 3}: {{0, 0}, {-1, 0}, {2, 0}, {-1, 2}, {2, -1}},
+	}
+)
+
+func srsKicks(size, from, to int) [][2]int {
+	table := srsKicksJLSTZ
+	switch size {
+	case 2:
+		return [][2]int{{0, 0}}
+	case 4:
+		table = srsKicksI
+	}
+
+	kicks := make([][2]int, 0, len(table[[2]int{from, to}]))
+	for _, kick := range table[[2]int{from, to}] {
+		kicks = append(kicks, [2]int{kick[0], -kick[1]})
+	}
+	return kicks
+}
+
+var (
+	SimpleRotation   RotationSystem = simpleRotation{}
+	SuperRotation    RotationSystem = boxRotation{kicks: srsKicks}
+	NintendoRotation RotationSystem = nintendoRotation{}
+	ArikaRotation    RotationSystem = arikaRotation{}
+)
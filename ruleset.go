@@ -0,0 +1,280 @@
+package tetris
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	frame    = time.Second / 60
+	nesFrame = 16639 * time.Microsecond
+
+	Gravity20G = frame / 20
+)
+
+type TopOut struct {
+	BlockOut      bool
+	LockOut       bool
+	GarbageTopOut bool
+}
+
+type Scoring interface {
+	Score(event ClearEvent, level int) int
+	Level(startLevel, lines int) int
+}
+
+type Ruleset struct {
+	Name           string
+	Rotation       RotationSystem
+	Randomizer     func(seed int64, tetrominos ...Tetromino) TetrominoGetter
+	LockDelay      time.Duration
+	LockResets     int
+	ARE            time.Duration
+	LineClearDelay time.Duration
+	Spawn          SpawnRules
+	Hold           bool
+	Scoring        Scoring
+	StartLevel     int
+	Gravity        func(level int) time.Duration
+	DAS, ARR       time.Duration
+	TopOut         TopOut
+}
+
+var (
+	RulesetGuideline = Ruleset{
+		Name:     "guideline",
+		Rotation: SuperRotation,
+		Randomizer: func(seed int64, tetrominos ...Tetromino) TetrominoGetter {
+			return NewBagGetter(seed, tetrominos...)
+		},
+		LockDelay:  500 * time.Millisecond,
+		LockResets: 15,
+		Spawn:      GuidelineSpawnRules,
+		Hold:       true,
+		Scoring:    GuidelineScoring{},
+		StartLevel: 1,
+		Gravity:    guidelineGravity,
+		DAS:        10 * frame,
+		ARR:        2 * frame,
+		TopOut:     TopOut{BlockOut: true, LockOut: true, GarbageTopOut: true},
+	}
+
+	RulesetClassic = Ruleset{
+		Name:     "classic",
+		Rotation: NintendoRotation,
+		Randomizer: func(seed int64, tetrominos ...Tetromino) TetrominoGetter {
+			return NewNESGetter(seed, tetrominos...)
+		},
+		LockDelay:      0,
+		LockResets:     0,
+		ARE:            0,
+		LineClearDelay: 0,
+		Spawn:          ClassicSpawnRules,
+		Hold:           false,
+		Scoring:        ClassicScoring{},
+		StartLevel:     0,
+		Gravity:        classicGravity,
+		DAS:            16 * nesFrame,
+		ARR:            6 * nesFrame,
+		TopOut:         TopOut{BlockOut: true},
+	}
+
+	RulesetTGM = Ruleset{
+		Name:     "tgm",
+		Rotation: ArikaRotation,
+		Randomizer: func(seed int64, tetrominos ...Tetromino) TetrominoGetter {
+			return NewHistoryGetter(seed, 4, 4, tetrominos...)
+		},
+		LockDelay:      30 * frame,
+		LockResets:     0,
+		ARE:            30 * frame,
+		LineClearDelay: 41 * frame,
+		Spawn:          ArikaSpawnRules,
+		Hold:           false,
+		Scoring:        TGMScoring{},
+		StartLevel:     0,
+		Gravity:        tgmGravity,
+		DAS:            16 * frame,
+		ARR:            frame,
+		TopOut:         TopOut{BlockOut: true},
+	}
+
+	RulesetMaster = Ruleset{
+		Name:     "master",
+		Rotation: ArikaRotation,
+		Randomizer: func(seed int64, tetrominos ...Tetromino) TetrominoGetter {
+			return NewHistoryGetter(seed, 4, 6, tetrominos...)
+		},
+		LockDelay:      30 * frame,
+		LockResets:     0,
+		ARE:            25 * frame,
+		LineClearDelay: 40 * frame,
+		Spawn:          ArikaSpawnRules,
+		Hold:           false,
+		Scoring:        TGMScoring{},
+		StartLevel:     0,
+		Gravity: func(level int) time.Duration {
+			return Gravity20G
+		},
+		DAS:    14 * frame,
+		ARR:    frame,
+		TopOut: TopOut{BlockOut: true},
+	}
+
+	Rulesets = []Ruleset{RulesetGuideline, RulesetClassic, RulesetTGM, RulesetMaster}
+)
+
+func FindRuleset(name string) (Ruleset, bool) {
+	for _, ruleset := range Rulesets {
+		if ruleset.Name == name {
+			return ruleset, true
+		}
+	}
+	return Ruleset{}, false
+}
+
+func WithRuleset(ruleset Ruleset) BoardOption {
+	return func(board *Board) {
+		board.ruleset = ruleset
+	}
+}
+
+func (b *Board) applyRuleset() {
+	b.randomizer = b.ruleset.Randomizer
+	b.rotation = b.ruleset.Rotation
+	b.lockDelay = b.ruleset.LockDelay
+	b.lockResets = b.ruleset.LockResets
+	b.are = b.ruleset.ARE
+	b.lineClearDelay = b.ruleset.LineClearDelay
+	b.spawnRules = b.ruleset.Spawn
+	b.holdEnabled = b.ruleset.Hold
+	b.scoring = b.ruleset.Scoring
+	b.startLevel = b.ruleset.StartLevel
+	b.gravity = b.ruleset.Gravity
+	b.topOut = b.ruleset.TopOut
+	for _, override := range b.ruleOverrides {
+		override(b)
+	}
+}
+
+func WithStartLevel(level int) BoardOption {
+	if level < 0 {
+		panic(fmt.Errorf("start level cannot be negative"))
+	}
+	return func(board *Board) {
+		board.ruleOverrides = append(board.ruleOverrides, func(board *Board) {
+			board.startLevel = level
+		})
+	}
+}
+
+func guidelineGravity(level int) time.Duration {
+	seconds := math.Pow(0.8-float64(level-1)*0.007, float64(level-1))
+	return maxDuration(time.Duration(seconds*float64(time.Second)), Gravity20G)
+}
+
+func tgmGravity(level int) time.Duration {
+	steps := []struct{ level, gravity int }{
+		{0, 4}, {30, 6}, {35, 8}, {40, 10}, {50, 12}, {60, 16}, {70, 32}, {80, 48},
+		{90, 64}, {100, 80}, {120, 96}, {140, 112}, {160, 128}, {170, 144}, {200, 4},
+		{220, 32}, {230, 64}, {233, 96}, {236, 128}, {239, 160}, {243, 192}, {247, 224},
+		{251, 256}, {300, 512}, {330, 768}, {360, 1024}, {400, 1280}, {420, 1024},
+		{450, 768}, {500, 5120},
+	}
+
+	gravity := steps[0].gravity
+	for _, step := range steps {
+		if level >= step.level {
+			gravity = step.gravity
+		}
+	}
+	return 256 * frame / time.Duration(gravity)
+}
+
+func classicGravity(level int) time.Duration {
+	frames := []int{48, 43, 38, 33, 28, 23, 18, 13, 8, 6, 5, 5, 5, 4, 4, 4, 3, 3, 3}
+	switch {
+	case level < len(frames):
+		return time.Duration(frames[level]) * nesFrame
+	case level < 29:
+		return 2 * nesFrame
+	default:
+		return nesFrame
+	}
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+type GuidelineScoring struct{}
+
+func (GuidelineScoring) Score(event ClearEvent, level int) int {
+	lines := [5]int{0, 100, 300, 500, 800}
+	tSpins := [4]int{400, 800, 1200, 1600}
+	perfectClears := [5]int{0, 800, 1200, 1800, 2000}
+
+	score := lines[minInt(event.Rows, 4)]
+	if event.TSpin {
+		score = tSpins[minInt(event.Rows, 3)]
+	}
+	if event.BackToBack {
+		score = score * 3 / 2
+	}
+	if event.Combo > 1 {
+		score += 50 * (event.Combo - 1)
+	}
+	if event.PerfectClear {
+		score += perfectClears[minInt(event.Rows, 4)]
+	}
+	return score * level
+}
+
+func (GuidelineScoring) Level(startLevel, lines int) int {
+	return startLevel + lines/10
+}
+
+type ClassicScoring struct{}
+
+func (ClassicScoring) Score(event ClearEvent, level int) int {
+	lines := [5]int{0, 40, 100, 300, 1200}
+	return lines[minInt(event.Rows, 4)] * (level + 1)
+}
+
+func (ClassicScoring) Level(startLevel, lines int) int {
+	firstLevelUp := minInt(startLevel*10+10, maxInt(100, startLevel*10-50))
+	if lines < firstLevelUp {
+		return startLevel
+	}
+	return startLevel + 1 + (lines-firstLevelUp)/10
+}
+
+type TGMScoring struct{}
+
+func (TGMScoring) Score(event ClearEvent, level int) int {
+	if event.Rows == 0 {
+		return 0
+	}
+
+	bravo := 1
+	if event.PerfectClear {
+		bravo = 4
+	}
+	combo := 1 + maxInt(event.Combo-1, 0)*(2*event.Rows-2)
+	return (level + event.Rows + 3) / 4 * event.Rows * maxInt(combo, 1) * bravo
+}
+
+func (TGMScoring) Level(startLevel, lines int) int {
+	return minInt(startLevel+lines, 999)
+}
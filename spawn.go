@@ -0,0 +1,117 @@
+package tetris
+
+import "time"
+
+type SpawnRule struct {
+	Rotation int
+	Column   int
+	Row      int
+}
+
+type SpawnRules map[Tetromino]SpawnRule
+
+var GuidelineSpawnRules = SpawnRules{
+	TetrominoT: {Rotation: 2, Column: 3, Row: -1},
+	TetrominoL: {Rotation: 1, Column: 3, Row: -1},
+	TetrominoJ: {Rotation: 3, Column: 3, Row: -1},
+	TetrominoS: {Rotation: 1, Column: 3, Row: -1},
+	TetrominoZ: {Rotation: 1, Column: 3, Row: -1},
+	TetrominoO: {Rotation: 0, Column: 4, Row: -1},
+	TetrominoI: {Rotation: 3, Column: 3, Row: -1},
+}
+
+var ClassicSpawnRules = SpawnRules{
+	TetrominoT: {Rotation: 0, Column: 4, Row: 1},
+	TetrominoL: {Rotation: 3, Column: 4, Row: 1},
+	TetrominoJ: {Rotation: 1, Column: 4, Row: 1},
+	TetrominoS: {Rotation: 1, Column: 4, Row: 1},
+	TetrominoZ: {Rotation: 1, Column: 4, Row: 1},
+	TetrominoO: {Rotation: 0, Column: 4, Row: 1},
+	TetrominoI: {Rotation: 1, Column: 3, Row: 0},
+}
+
+var ArikaSpawnRules = SpawnRules{
+	TetrominoT: {Rotation: 0, Column: 3, Row: 1},
+	TetrominoL: {Rotation: 3, Column: 3, Row: 1},
+	TetrominoJ: {Rotation: 1, Column: 3, Row: 1},
+	TetrominoS: {Rotation: 1, Column: 3, Row: 1},
+	TetrominoZ: {Rotation: 1, Column: 3, Row: 1},
+	TetrominoO: {Rotation: 0, Column: 4, Row: 1},
+	TetrominoI: {Rotation: 1, Column: 3, Row: 1},
+}
+
+func WithSpawnRules(rules SpawnRules) BoardOption {
+	return func(board *Board) {
+		board.ruleOverrides = append(board.ruleOverrides, func(board *Board) {
+			board.spawnRules = rules
+		})
+	}
+}
+
+func (r SpawnRules) orient(t Tetromino) Tetromino {
+	rule, ok := r[t]
+	if !ok {
+		return t
+	}
+	return rotateTetrominoTimes(t, rule.Rotation)
+}
+
+func (r SpawnRules) find(t Tetromino) (SpawnRule, bool) {
+	t = normalizeTetromino(t)
+	for key, rule := range r {
+		if normalizeTetromino(rotateTetrominoTimes(key, rule.Rotation)) == t {
+			return rule, true
+		}
+	}
+	return SpawnRule{}, false
+}
+
+func rotateTetrominoTimes(t Tetromino, times int) Tetromino {
+	for i := 0; i < (times%4+4)%4; i++ {
+		t = rotateTetromino(t)
+	}
+	return t
+}
+
+func normalizeTetromino(t Tetromino) Tetromino {
+	top, left, _, _ := tetrominoBounds(t)
+	cells := tetrominoCells(t)
+	for i, cell := range cells {
+		cells[i] = [2]int{cell[0] - top, cell[1] - left}
+	}
+	return newTetrominoFromCells(t.Size, cells)
+}
+
+func tetrominoBounds(t Tetromino) (top, left, bottom, right int) {
+	top, left, bottom, right = t.Size, t.Size, -1, -1
+	for y := 0; y < t.Size; y++ {
+		for x := 0; x < t.Size; x++ {
+			if !t.Block(y, x) {
+				continue
+			}
+			top, bottom = minInt(top, y), maxInt(bottom, y)
+			left, right = minInt(left, x), maxInt(right, x)
+		}
+	}
+	return
+}
+
+func (b *Board) spawnCurrentTetromino() {
+	top, left, bottom, right := tetrominoBounds(b.current)
+
+	column, row := (b.width-(right-left+1))/2, -1
+	if rule, ok := b.spawnRules.find(b.current); ok {
+		column, row = rule.Column+(b.width-10)/2, rule.Row
+	}
+
+	b.currentX = column - left
+	b.currentY = maxInt(-top, b.hiddenRows+row-bottom)
+	if b.currentY+bottom < b.hiddenRows && !b.isTouchGround() {
+		b.currentY++
+	}
+	b.currentRotation = 0
+	b.spawned, b.spawnX, b.inputs = b.current, b.currentX, 0
+	b.lastMoveRotation = false
+	b.groundedAt = time.Time{}
+	b.lockResetCount = 0
+}
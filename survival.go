@@ -0,0 +1,32 @@
+package tetris
+
+import "time"
+
+func WithRisingGarbage(interval func(rises int) time.Duration) BoardOption {
+	return func(board *Board) {
+		board.riseInterval = interval
+	}
+}
+
+func SurvivalInterval(rises int) time.Duration {
+	interval := 10 * time.Second
+	for i := 0; i < rises && interval > time.Second; i++ {
+		interval = interval * 9 / 10
+	}
+	return maxDuration(interval, time.Second)
+}
+
+func (b *Board) nextRise() time.Duration {
+	var at time.Duration
+	for i := 0; i <= b.garbageRises; i++ {
+		at += b.riseInterval(i)
+	}
+	return at
+}
+
+func (b *Board) applyRisingGarbage() {
+	for b.riseInterval != nil && !b.isOver && b.playTime() >= b.nextRise() {
+		b.addGarbage(1)
+		b.garbageRises++
+	}
+}
@@ -0,0 +1,51 @@
+package termui
+
+import (
+	"github.com/JoelOtter/termloop"
+	"github.com/jauhararifin/tetris"
+)
+
+func DrawBox(s *termloop.Screen, x, y int) {
+	for i := 0; i < 6; i++ {
+		s.RenderCell(x+i, y, &termloop.Cell{
+			Fg: termloop.ColorWhite,
+			Bg: termloop.ColorBlack,
+			Ch: '+',
+		})
+		s.RenderCell(x+i, y+5, &termloop.Cell{
+			Fg: termloop.ColorWhite,
+			Bg: termloop.ColorBlack,
+			Ch: '+',
+		})
+		s.RenderCell(x, y+i, &termloop.Cell{
+			Fg: termloop.ColorWhite,
+			Bg: termloop.ColorBlack,
+			Ch: '+',
+		})
+		s.RenderCell(x+5, y+i, &termloop.Cell{
+			Fg: termloop.ColorWhite,
+			Bg: termloop.ColorBlack,
+			Ch: '+',
+		})
+	}
+}
+
+func DrawTetromino(s *termloop.Screen, x, y int, t tetris.Tetromino, fg termloop.Attr) {
+	size := 4
+	if t.Size > size {
+		size = t.Size
+	}
+	for i := 0; i < size; i++ {
+		for j := 0; j < size; j++ {
+			ch := rune(0)
+			if t.Block(i, j) {
+				ch = '@'
+			}
+			s.RenderCell(x+j, y+i, &termloop.Cell{
+				Fg: fg,
+				Bg: termloop.ColorBlack,
+				Ch: ch,
+			})
+		}
+	}
+}
@@ -3,6 +3,7 @@ package tetris
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 	"sync"
 	"time"
 )
@@ -16,16 +17,21 @@ const (
 	ActionRotate
 	ActionSmash
 	ActionFill
+	ActionRotateClockwise
+	ActionHold
+	ActionShiftLeft
+	ActionShiftRight
+	ActionUseItem
 )
 
-type Tetromino [4][4]int
-
 var (
-	TetrominoT = Tetromino{{0, 0, 0, 0}, {1, 1, 1, 0}, {0, 1, 0, 0}, {0, 0, 0, 0}}
-	TetrominoL = Tetromino{{0, 1, 0, 0}, {0, 1, 0, 0}, {0, 1, 1, 0}, {0, 0, 0, 0}}
-	TetrominoZ = Tetromino{{0, 1, 0, 0}, {0, 1, 1, 0}, {0, 0, 1, 0}, {0, 0, 0, 0}}
-	TetrominoO = Tetromino{{0, 0, 0, 0}, {0, 1, 1, 0}, {0, 1, 1, 0}, {0, 0, 0, 0}}
-	TetrominoI = Tetromino{{0, 1, 0, 0}, {0, 1, 0, 0}, {0, 1, 0, 0}, {0, 1, 0, 0}}
+	TetrominoT = NewTetromino("....", "###.", ".#..", "....")
+	TetrominoL = NewTetromino(".#..", ".#..", ".##.", "....")
+	TetrominoJ = NewTetromino("..#.", "..#.", ".##.", "....")
+	TetrominoS = NewTetromino(".#..", ".##.", "..#.", "....")
+	TetrominoZ = NewTetromino("..#.", ".##.", ".#..", "....")
+	TetrominoO = NewTetromino("....", ".##.", ".##.", "....")
+	TetrominoI = NewTetromino(".#..", ".#..", ".#..", ".#..")
 )
 
 type Tile int
@@ -51,25 +57,140 @@ func (f CompleteHandlerFunc) OnCompleted(rows int) {
 	f(rows)
 }
 
+type ClearEvent struct {
+	Rows         int
+	TSpin        bool
+	PerfectClear bool
+	Combo        int
+	BackToBack   bool
+	Attack       int
+	Score        int
+	Finesse      int
+	Chain        int
+}
+
+type ClearHandler interface {
+	OnClear(event ClearEvent)
+}
+
+type ClearHandlerFunc func(event ClearEvent)
+
+func (f ClearHandlerFunc) OnClear(event ClearEvent) {
+	f(event)
+}
+
+type GetterState struct {
+	Seed  int64
+	Drawn int
+	Queue []Tetromino
+}
+
+type StatefulGetter interface {
+	TetrominoGetter
+	GetState() GetterState
+	SetState(state GetterState)
+}
+
 type State struct {
 	Tiles              [][]Tile
+	LockedAt           [][]time.Time
 	Current, Next      Tetromino
 	CurrentX, CurrentY int
+	Rotation           int
+	Hold               Tetromino
+	HoldUsed           bool
 	IsOver             bool
+	IsCompleted        bool
+	Score, Lines       int
+	Level              int
+	Combo              int
+	BackToBack         bool
+	PendingGarbage     int
+	DigRemaining       int
+	GarbageRises       int
+	Items              []Item
+	PreviewHidden      bool
+	SpedUp             bool
+	Elapsed            time.Duration
+	Getter             *GetterState
+	GarbageRandomizer  *GetterState
+	ItemRandomizer     *GetterState
 }
 
 type Board struct {
-	tetrominoGetter TetrominoGetter
-	completeHandler CompleteHandler
-	width, height   int
+	tetrominoGetter   TetrominoGetter
+	ruleset           Ruleset
+	ruleOverrides     []BoardOption
+	randomizer        func(seed int64, tetrominos ...Tetromino) TetrominoGetter
+	seed              int64
+	tetrominos        []Tetromino
+	completeHandler   CompleteHandler
+	clearHandler      ClearHandler
+	width, height     int
+	hiddenRows        int
+	spawnRules        SpawnRules
+	rotation          RotationSystem
+	lockDelay         time.Duration
+	lockResets        int
+	are               time.Duration
+	lineClearDelay    time.Duration
+	holdEnabled       bool
+	scoring           Scoring
+	startLevel        int
+	gravity           func(level int) time.Duration
+	topOut            TopOut
+	garbageRandomizer *countingRand
+	garbageMessiness  float64
+	garbageDelay      time.Duration
+	attackTable       AttackTable
+	lineGoal          int
+	timeLimit         time.Duration
+	digTotal, digRows int
+	riseInterval      func(rises int) time.Duration
 
 	tiles              [][]Tile
 	current, next      Tetromino
 	currentX, currentY int
+	currentRotation    int
+	hold               Tetromino
+	holdUsed           bool
 	isOver             bool
-
+	isCompleted        bool
+	lastMoveRotation   bool
+	groundedAt         time.Time
+	lockResetCount     int
+	entryAt            time.Time
+	spawned            Tetromino
+	spawnX, inputs     int
+	score, lines       int
+	level              int
+
+	pendingGarbage []pendingGarbage
+	combo          int
+	backToBack     bool
+
+	clockStart   time.Time
+	clockElapsed time.Duration
+	digQueued    int
+	garbageRises int
+
+	events      []ClearEvent
 	renderFrame [][]Tile
-	m           *sync.RWMutex
+	lockedAt    [][]time.Time
+	transforms  []RenderTransform
+	big         bool
+	bigFrame    [][]Tile
+	cascade     bool
+	puzzle      *Puzzle
+
+	itemChance         float64
+	itemRandomizer     *countingRand
+	itemHandler        ItemHandler
+	items, usedItems   []Item
+	speedUntil         time.Time
+	previewHiddenUntil time.Time
+
+	m *sync.RWMutex
 }
 
 type BoardOption func(*Board)
@@ -84,6 +205,15 @@ func WithSize(width, height int) BoardOption {
 	}
 }
 
+func WithHiddenRows(rows int) BoardOption {
+	if rows < 0 {
+		panic(fmt.Errorf("hidden rows cannot be negative"))
+	}
+	return func(board *Board) {
+		board.hiddenRows = rows
+	}
+}
+
 func WithGetter(tetrominoGetter TetrominoGetter) BoardOption {
 	return func(board *Board) {
 		board.tetrominoGetter = tetrominoGetter
@@ -96,108 +226,471 @@ func WithCompleteHandler(handler CompleteHandler) BoardOption {
 	}
 }
 
+func WithClearHandler(handler ClearHandler) BoardOption {
+	return func(board *Board) {
+		board.clearHandler = handler
+	}
+}
+
+func WithSeed(seed int64) BoardOption {
+	return func(board *Board) {
+		board.seed = seed
+		board.garbageRandomizer = newCountingRand(seed)
+		board.itemRandomizer = newCountingRand(seed + 1)
+	}
+}
+
+func WithGarbageSeed(seed int64) BoardOption {
+	return func(board *Board) {
+		board.garbageRandomizer = newCountingRand(seed)
+	}
+}
+
+func WithGarbageMessiness(messiness float64) BoardOption {
+	if messiness < 0 || messiness > 1 {
+		panic(fmt.Errorf("garbage messiness should be between 0 and 1"))
+	}
+	return func(board *Board) {
+		board.garbageMessiness = messiness
+	}
+}
+
 func NewBoard(options ...BoardOption) *Board {
 	board := &Board{
-		tetrominoGetter: NewRandomGetter(time.Now().UnixNano()),
-		width:           10,
-		height:          24,
-		m:               &sync.RWMutex{},
+		width:             10,
+		height:            24,
+		hiddenRows:        0,
+		ruleset:           RulesetGuideline,
+		seed:              time.Now().UnixNano(),
+		garbageRandomizer: newCountingRand(time.Now().UnixNano()),
+		itemRandomizer:    newCountingRand(time.Now().UnixNano() + 1),
+		garbageMessiness:  0,
+		garbageDelay:      0,
+		attackTable:       GuidelineAttackTable,
+		m:                 &sync.RWMutex{},
 	}
 	for _, opt := range options {
 		opt(board)
 	}
-
-	board.current = board.tetrominoGetter.Next()
-	board.currentX = board.width/2 - 1
-	board.currentY = 0
-	board.next = board.tetrominoGetter.Next()
-	board.isOver = false
+	board.applyRuleset()
+	if board.tetrominoGetter == nil {
+		board.tetrominoGetter = board.randomizer(board.seed, board.tetrominos...)
+	}
+	board.applyBigMode()
+	board.height += board.hiddenRows
+	board.level = board.startLevel
 
 	board.tiles = make([][]Tile, board.height, board.height)
-	board.renderFrame = make([][]Tile, board.height, board.height)
 	for i := 0; i < board.height; i++ {
 		board.tiles[i] = make([]Tile, board.width, board.width)
-		board.renderFrame[i] = make([]Tile, board.width, board.width)
 		for j := 0; j < board.width; j++ {
 			board.tiles[i][j] = TileEmpty
 		}
 	}
+	board.fillPuzzleTiles()
+	board.lockedAt = board.lockTimes(board.tiles)
+	board.digQueued = board.digTotal
+	board.feedDigRows()
+
+	board.current = board.spawnRules.orient(board.tetrominoGetter.Next())
+	board.next = board.spawnRules.orient(board.tetrominoGetter.Next())
+	board.spawnCurrentTetromino()
+	board.isOver = false
+	board.clockStart = time.Now()
+
+	board.renderFrame = make([][]Tile, board.height-board.hiddenRows, board.height-board.hiddenRows)
+	for i := range board.renderFrame {
+		board.renderFrame[i] = make([]Tile, board.width, board.width)
+	}
+	if board.big {
+		board.bigFrame = make([][]Tile, len(board.renderFrame)*2, len(board.renderFrame)*2)
+		for i := range board.bigFrame {
+			board.bigFrame[i] = make([]Tile, board.width*2, board.width*2)
+		}
+	}
+	board.applyInstantGravity()
 
 	return board
 }
 
 func (b *Board) SetState(state State) {
 	b.m.Lock()
-	b.m.Unlock()
+	defer b.m.Unlock()
 
 	b.current = state.Current
 	b.currentX = state.CurrentX
 	b.currentY = state.CurrentY
-	b.tiles = state.Tiles
+	b.currentRotation = state.Rotation
+	b.hold = state.Hold
+	b.holdUsed = state.HoldUsed
+	b.lockedAt = b.lockTimes(state.Tiles)
+	if sameShape(state.LockedAt, state.Tiles) {
+		b.lockedAt = copyLockTimes(state.LockedAt)
+	}
+	b.tiles = copyTiles(state.Tiles)
 	b.isOver = state.IsOver
+	b.isCompleted = state.IsCompleted
 	b.next = state.Next
+	b.score = state.Score
+	b.lines = state.Lines
+	b.level = state.Level
+	b.groundedAt = time.Time{}
+	b.lockResetCount = 0
+	b.spawned, b.inputs = Tetromino{}, 0
+	b.digQueued = maxInt(state.DigRemaining-b.countGarbageRows(), 0)
+	b.garbageRises = state.GarbageRises
+	b.items = append([]Item(nil), state.Items...)
+	b.clockElapsed, b.clockStart = state.Elapsed, time.Now()
+	if b.isOver {
+		b.clockStart = time.Time{}
+	}
+	b.combo = state.Combo
+	b.backToBack = state.BackToBack
+	b.pendingGarbage = nil
+	if state.PendingGarbage > 0 {
+		b.pendingGarbage = []pendingGarbage{{lines: state.PendingGarbage, readyAt: time.Now().Add(b.garbageDelay)}}
+	}
+
+	if getter, ok := b.tetrominoGetter.(StatefulGetter); ok && state.Getter != nil {
+		getter.SetState(*state.Getter)
+	}
+	if state.GarbageRandomizer != nil {
+		b.garbageRandomizer.SetState(*state.GarbageRandomizer)
+	}
+	if state.ItemRandomizer != nil {
+		b.itemRandomizer.SetState(*state.ItemRandomizer)
+	}
 }
 
 func (b *Board) GetState() State {
 	b.m.RLock()
-	b.m.RUnlock()
+	defer b.m.RUnlock()
+
+	state := State{
+		Tiles:       copyTiles(b.tiles),
+		LockedAt:    copyLockTimes(b.lockedAt),
+		Current:     b.current,
+		Next:        b.next,
+		CurrentX:    b.currentX,
+		CurrentY:    b.currentY,
+		Rotation:    b.currentRotation,
+		Hold:        b.hold,
+		HoldUsed:    b.holdUsed,
+		IsOver:      b.isOver,
+		IsCompleted: b.isCompleted,
+		Score:       b.score,
+		Lines:       b.lines,
+		Level:       b.level,
+
+		Combo:          b.combo,
+		BackToBack:     b.backToBack,
+		PendingGarbage: b.countPendingGarbage(),
+		DigRemaining:   b.digRemaining(),
+		GarbageRises:   b.garbageRises,
+		Items:          append([]Item(nil), b.items...),
+		PreviewHidden:  time.Now().Before(b.previewHiddenUntil),
+		SpedUp:         time.Now().Before(b.speedUntil),
+		Elapsed:        b.playTime(),
+	}
+	if getter, ok := b.tetrominoGetter.(StatefulGetter); ok {
+		getterState := getter.GetState()
+		state.Getter = &getterState
+	}
+	garbageState, itemState := b.garbageRandomizer.GetState(), b.itemRandomizer.GetState()
+	state.GarbageRandomizer, state.ItemRandomizer = &garbageState, &itemState
+	return state
+}
 
-	return State{
-		Tiles:    b.tiles,
-		Current:  b.current,
-		Next:     b.next,
-		CurrentX: b.currentX,
-		CurrentY: b.currentY,
-		IsOver:   b.isOver,
+func (s State) ComboStatus() string {
+	status := ""
+	if s.Combo > 1 {
+		status = fmt.Sprintf("Combo: %d", s.Combo-1)
+	}
+	if s.BackToBack {
+		status += " B2B"
 	}
+	return strings.TrimSpace(status)
+}
+
+func copyTiles(tiles [][]Tile) [][]Tile {
+	result := make([][]Tile, len(tiles), len(tiles))
+	for i := range tiles {
+		result[i] = make([]Tile, len(tiles[i]), len(tiles[i]))
+		copy(result[i], tiles[i])
+	}
+	return result
 }
 
 func (b *Board) Next() Tetromino {
 	b.m.RLock()
-	b.m.RUnlock()
+	defer b.m.RUnlock()
 	return b.next
 }
 
+func (b *Board) Gravity() time.Duration {
+	b.m.RLock()
+	defer b.m.RUnlock()
+	return b.currentGravity()
+}
+
+func (b *Board) currentGravity() time.Duration {
+	return b.speedUpGravity(b.gravity(b.level))
+}
+
+func (b *Board) Elapsed() time.Duration {
+	b.m.RLock()
+	defer b.m.RUnlock()
+	return b.playTime()
+}
+
+func (b *Board) TickDelay() time.Duration {
+	b.m.RLock()
+	defer b.m.RUnlock()
+
+	delay := b.currentGravity()
+	if b.isWaiting() {
+		delay = time.Until(b.entryAt)
+	} else if !b.groundedAt.IsZero() && b.lockDelay > 0 {
+		delay = minDuration(delay, b.lockDelay-time.Since(b.groundedAt))
+	}
+	if b.timeLimit > 0 {
+		delay = minDuration(delay, b.timeLimit-b.playTime())
+	}
+	if b.riseInterval != nil {
+		delay = minDuration(delay, b.nextRise()-b.playTime())
+	}
+	return maxDuration(delay, frame)
+}
+
 func (b *Board) Apply(action Action) {
 	b.m.Lock()
+	b.updateClock()
+	b.apply(action)
+	b.updateClock()
+	events, items := b.events, b.usedItems
+	b.events, b.usedItems = nil, nil
+	b.m.Unlock()
+
+	b.notify(events)
+	b.notifyItems(items)
+}
+
+func (b *Board) AddGarbage(lines int) {
+	b.m.Lock()
+	if !b.isOver {
+		b.addGarbage(lines)
+	}
+	b.updateClock()
+	events := b.events
+	b.events = nil
 	b.m.Unlock()
 
+	b.notify(events)
+}
+
+func (b *Board) notify(events []ClearEvent) {
+	for _, event := range events {
+		if b.completeHandler != nil {
+			b.completeHandler.OnCompleted(event.Rows)
+		}
+		if b.clearHandler != nil {
+			b.clearHandler.OnClear(event)
+		}
+	}
+}
+
+func (b *Board) apply(action Action) {
 	if b.isOver {
 		return
 	}
+	b.applyRisingGarbage()
+	if b.isOver {
+		return
+	}
+	if b.isWaiting() {
+		if action == ActionTick && !time.Now().Before(b.entryAt) {
+			b.spawnNextTetromino()
+			b.applyInstantGravity()
+		}
+		return
+	}
 
 	switch action {
 	case ActionTick:
 		b.applyTick()
 	case ActionGoLeft:
+		b.inputs++
 		b.applyGoLeft()
 	case ActionGoRight:
+		b.inputs++
+		b.applyGoRight()
+	case ActionShiftLeft:
+		b.applyGoLeft()
+	case ActionShiftRight:
 		b.applyGoRight()
 	case ActionRotate:
-		b.applyRotate()
+		b.inputs++
+		b.applyRotate(false)
+	case ActionRotateClockwise:
+		b.inputs++
+		b.applyRotate(true)
 	case ActionSmash:
 		b.applySmash()
 	case ActionFill:
 		b.applyFill()
+	case ActionHold:
+		b.applyHold()
+	case ActionUseItem:
+		b.applyUseItem()
 	}
+	b.applyInstantGravity()
 }
 
 func (b *Board) applyTick() {
-	if b.isTouchGround() {
-		b.fillTilesWithCurrentTetromino()
-		b.popCompletedRows()
-		b.setupNextTetromino()
-		if b.isOverlapGround() {
-			b.isOver = true
+	if !b.isTouchGround() {
+		rows := 1
+		if gravity := b.currentGravity(); gravity > 0 && gravity < frame {
+			rows = int(frame / gravity)
+		}
+		for i := 0; i < rows && !b.isTouchGround(); i++ {
+			b.stepDown()
+		}
+		if b.isTouchGround() {
+			b.groundedAt = time.Now()
+		}
+		return
+	}
+
+	now := time.Now()
+	if b.groundedAt.IsZero() {
+		b.groundedAt = now
+	}
+	if now.Sub(b.groundedAt) >= b.lockDelay {
+		b.lockCurrentTetromino()
+	}
+}
+
+func (b *Board) resetLockDelay() {
+	if b.groundedAt.IsZero() {
+		return
+	}
+	if b.lockResets >= 0 && b.lockResetCount >= b.lockResets {
+		return
+	}
+	b.lockResetCount++
+	b.groundedAt = time.Now()
+}
+
+func (b *Board) lockCurrentTetromino() {
+	tSpin := b.lastMoveRotation && b.isTSpin()
+	lockOut := b.isAboveVisibleField()
+	b.fillTilesWithCurrentTetromino()
+	rows := b.popCompletedRows()
+
+	event := b.recordClear(ClearEvent{Rows: rows, TSpin: tSpin, Finesse: b.finesseFaults()})
+	for chain := 1; b.cascade && rows > 0; chain++ {
+		b.applyCascadeGravity()
+		chainRows := b.popCompletedRows()
+		if chainRows == 0 {
+			break
 		}
+		b.recordChain(ClearEvent{Rows: chainRows, Chain: chain})
+	}
+
+	if b.digTotal > 0 {
+		b.feedDigRows()
+		if b.digRemaining() == 0 {
+			b.isCompleted = true
+		}
+	}
+	if b.lineGoal > 0 && b.lines >= b.lineGoal {
+		b.isCompleted = true
+	}
+	if b.isPuzzleSolved(event) {
+		b.isCompleted = true
+	}
+	if b.isCompleted {
+		b.isOver = true
+		return
+	}
+
+	if (lockOut && b.topOut.LockOut) || b.isOver {
+		b.isOver = true
+		return
+	}
+
+	delay := b.are
+	if rows > 0 {
+		delay += b.lineClearDelay
+	}
+	if delay > 0 {
+		b.current = Tetromino{}
+		b.entryAt = time.Now().Add(delay)
+		return
+	}
+	b.spawnNextTetromino()
+}
+
+func (b *Board) recordClear(event ClearEvent) ClearEvent {
+	if event.Rows > 0 {
+		b.combo++
+		difficult := event.Rows == 4 || event.TSpin
+		event.Combo = b.combo
+		event.BackToBack = difficult && b.backToBack
+		event.PerfectClear = b.isEmpty()
+		event.Attack = b.attackTable.attack(event.Rows, event.TSpin, b.combo-1, event.BackToBack, event.PerfectClear)
+		event.Attack = b.cancelPendingGarbage(event.Attack)
+		b.backToBack = difficult
+		b.awardItem()
 	} else {
+		b.combo = 0
+		b.insertPendingGarbage()
+	}
+
+	event.Score = b.scoring.Score(event, b.level)
+	b.score += event.Score
+	b.lines += event.Rows
+	b.level = maxInt(b.level, b.scoring.Level(b.startLevel, b.lines))
+	b.events = append(b.events, event)
+	return event
+}
+
+func (b *Board) recordChain(event ClearEvent) {
+	event.PerfectClear = b.isEmpty()
+	event.Score = b.scoring.Score(event, b.level)
+	b.score += event.Score
+	b.lines += event.Rows
+	b.level = maxInt(b.level, b.scoring.Level(b.startLevel, b.lines))
+	b.events = append(b.events, event)
+}
+
+func (b *Board) spawnNextTetromino() {
+	b.setupNextTetromino()
+	if b.isWaiting() && b.holdEnabled && b.hold != (Tetromino{}) {
+		b.current, b.hold = b.hold, Tetromino{}
+		b.spawnCurrentTetromino()
+	}
+	if b.isWaiting() || (b.isOverlapGround() && b.topOut.BlockOut) {
+		b.isOver = true
+	}
+}
+
+func (b *Board) isWaiting() bool {
+	return b.current == Tetromino{}
+}
+
+func (b *Board) applyInstantGravity() {
+	if b.isOver || b.isWaiting() || b.currentGravity() > frame/20 {
+		return
+	}
+	for !b.isTouchGround() {
 		b.stepDown()
 	}
 }
 
 func (b *Board) isTouchGround() bool {
-	for y := 0; y < 4; y++ {
-		for x := 0; x < 4; x++ {
+	for y := 0; y < b.current.Size; y++ {
+		for x := 0; x < b.current.Size; x++ {
 			if b.isTetrominoUnitOverlapBlock(y, x) || b.isTetrominoUnitTouchGround(y, x) {
 				return true
 			}
@@ -208,7 +701,7 @@ func (b *Board) isTouchGround() bool {
 
 func (b *Board) isTetrominoUnitTouchGround(y, x int) bool {
 	t := b.current
-	if t[y][x] != 1 {
+	if !t.Block(y, x) {
 		return false
 	}
 	if b.currentY+y+1 >= b.height {
@@ -225,7 +718,7 @@ func (b *Board) isTetrominoUnitTouchGround(y, x int) bool {
 
 func (b *Board) isTetrominoUnitOverlapBlock(y, x int) bool {
 	t := b.current
-	if t[y][x] != 1 {
+	if !t.Block(y, x) {
 		return false
 	}
 	if b.currentY+y >= b.height || b.currentY+y < 0 || b.currentX+x >= b.width || b.currentX+x < 0 {
@@ -238,8 +731,8 @@ func (b *Board) isTetrominoUnitOverlapBlock(y, x int) bool {
 }
 
 func (b *Board) isOverlapGround() bool {
-	for y := 0; y < 4; y++ {
-		for x := 0; x < 4; x++ {
+	for y := 0; y < b.current.Size; y++ {
+		for x := 0; x < b.current.Size; x++ {
 			if b.isTetrominoUnitOverlapBlock(y, x) {
 				return true
 			}
@@ -250,16 +743,83 @@ func (b *Board) isOverlapGround() bool {
 
 func (b *Board) fillTilesWithCurrentTetromino() {
 	t := b.current
-	for y := 0; y < 4; y++ {
-		for x := 0; x < 4; x++ {
-			if t[y][x] == 1 {
+	for y := 0; y < t.Size; y++ {
+		for x := 0; x < t.Size; x++ {
+			if t.Block(y, x) {
 				b.tiles[b.currentY+y][b.currentX+x] = TileNormalBlock
+				b.lockedAt[b.currentY+y][b.currentX+x] = time.Now()
 			}
 		}
 	}
 }
 
-func (b *Board) popCompletedRows() {
+func (b *Board) isAboveVisibleField() bool {
+	for y := 0; y < b.current.Size; y++ {
+		for x := 0; x < b.current.Size; x++ {
+			if b.current.Block(y, x) && b.currentY+y >= b.hiddenRows {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (b *Board) isEmpty() bool {
+	for y := 0; y < b.height; y++ {
+		for x := 0; x < b.width; x++ {
+			if b.tiles[y][x] != TileEmpty {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (b *Board) isTSpin() bool {
+	centerY, centerX, ok := tetrominoCenter(b.current)
+	if !ok || len(tetrominoCells(b.current)) != 4 {
+		return false
+	}
+
+	corners := 0
+	for _, d := range [][2]int{{-1, -1}, {-1, 1}, {1, -1}, {1, 1}} {
+		y := b.currentY + centerY + d[0]
+		x := b.currentX + centerX + d[1]
+		if y < 0 || y >= b.height || x < 0 || x >= b.width || b.tiles[y][x] != TileEmpty {
+			corners++
+		}
+	}
+	return corners >= 3
+}
+
+func tetrominoCenter(t Tetromino) (y, x int, ok bool) {
+	for y := 0; y < t.Size; y++ {
+		for x := 0; x < t.Size; x++ {
+			if !t.Block(y, x) {
+				continue
+			}
+			neighbours := 0
+			if t.Block(y-1, x) {
+				neighbours++
+			}
+			if t.Block(y+1, x) {
+				neighbours++
+			}
+			if t.Block(y, x-1) {
+				neighbours++
+			}
+			if t.Block(y, x+1) {
+				neighbours++
+			}
+			if neighbours == 3 {
+				return y, x, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func (b *Board) popCompletedRows() int {
 	completedRowsCount := 0
 	for y := b.height - 1; y >= 0; y-- {
 		completedRowsBelow := completedRowsCount
@@ -271,18 +831,17 @@ func (b *Board) popCompletedRows() {
 			for x := 0; x < b.width; x++ {
 				b.tiles[y+completedRowsBelow][x] = b.tiles[y][x]
 				b.tiles[y][x] = TileEmpty
+				b.lockedAt[y+completedRowsBelow][x] = b.lockedAt[y][x]
 			}
 		}
 	}
 
-	if b.completeHandler != nil {
-		b.completeHandler.OnCompleted(completedRowsCount)
-	}
+	return completedRowsCount
 }
 
 func (b *Board) isRowCompleted(row int) bool {
 	for x := 0; x < b.width; x++ {
-		if b.tiles[row][x] != TileNormalBlock {
+		if b.tiles[row][x] == TileEmpty {
 			return false
 		}
 	}
@@ -291,13 +850,15 @@ func (b *Board) isRowCompleted(row int) bool {
 
 func (b *Board) setupNextTetromino() {
 	b.current = b.next
-	b.next = b.tetrominoGetter.Next()
-	b.currentY = 0
-	b.currentX = b.width/2 - 1
+	b.next = b.spawnRules.orient(b.tetrominoGetter.Next())
+	b.holdUsed = false
+	b.spawnCurrentTetromino()
 }
 
 func (b *Board) stepDown() {
 	b.currentY++
+	b.lastMoveRotation = false
+	b.groundedAt = time.Time{}
 }
 
 func (b *Board) applyGoLeft() {
@@ -305,13 +866,15 @@ func (b *Board) applyGoLeft() {
 		return
 	}
 	b.currentX--
+	b.lastMoveRotation = false
+	b.resetLockDelay()
 }
 
 func (b *Board) isHitWallOrTile() (hitLeftWall, hitRightWall bool) {
 	t := b.current
-	for y := 0; y < 4; y++ {
-		for x := 0; x < 4; x++ {
-			if t[y][x] != 1 {
+	for y := 0; y < t.Size; y++ {
+		for x := 0; x < t.Size; x++ {
+			if !t.Block(y, x) {
 				continue
 			}
 			hitLeft, hitRight := b.isTetrominoUnitHitWallOrTile(y, x)
@@ -332,7 +895,7 @@ func (b *Board) isHitWallOrTile() (hitLeftWall, hitRightWall bool) {
 
 func (b *Board) isTetrominoUnitHitWallOrTile(y, x int) (hitLeftWall, hitRightWall bool) {
 	t := b.current
-	if t[y][x] != 1 {
+	if !t.Block(y, x) {
 		return false, false
 	}
 
@@ -356,44 +919,93 @@ func (b *Board) applyGoRight() {
 		return
 	}
 	b.currentX++
+	b.lastMoveRotation = false
+	b.resetLockDelay()
 }
 
-func (b *Board) applyRotate() {
-	initialTetromino := b.current
-	b.current = rotateTetromino(b.current)
-	if b.isOverlapGround() {
-		b.current = initialTetromino
+func (b *Board) applyRotate(clockwise bool) {
+	initialTetromino, initialX, initialY := b.current, b.currentX, b.currentY
+	rotated, kicks := b.rotation.Rotate(b.current, b.currentRotation, clockwise)
+	for i, kick := range kicks {
+		b.current = rotated
+		b.currentX = initialX + kick[0]
+		b.currentY = initialY + kick[1]
+		if b.isOverlapGround() {
+			if rule, ok := b.rotation.(kickRule); ok && i == 0 && !rule.canKick(rotated, b.isTetrominoUnitOverlapBlock) {
+				break
+			}
+			continue
+		}
+
+		if clockwise {
+			b.currentRotation = (b.currentRotation + 1) % 4
+		} else {
+			b.currentRotation = (b.currentRotation + 3) % 4
+		}
+		b.lastMoveRotation = true
+		b.resetLockDelay()
+		return
+	}
+	b.current, b.currentX, b.currentY = initialTetromino, initialX, initialY
+}
+
+func (b *Board) applyHold() {
+	if !b.holdEnabled || b.holdUsed {
+		return
+	}
+
+	current := b.current
+	for i := 0; i < b.currentRotation; i++ {
+		current, _ = b.rotation.Rotate(current, (b.currentRotation-i+4)%4, false)
+	}
+
+	if b.hold == (Tetromino{}) && b.next == (Tetromino{}) {
+		return
+	}
+	if b.hold == (Tetromino{}) {
+		b.current = b.next
+		b.next = b.spawnRules.orient(b.tetrominoGetter.Next())
+	} else {
+		b.current = b.hold
+	}
+	b.hold = normalizeTetromino(current)
+	b.holdUsed = true
+
+	b.spawnCurrentTetromino()
+	if b.isOverlapGround() && b.topOut.BlockOut {
+		b.isOver = true
 	}
 }
 
 func rotateTetromino(t Tetromino) Tetromino {
-	return Tetromino{
-		{t[0][3], t[1][3], t[2][3], t[3][3],},
-		{t[0][2], t[1][2], t[2][2], t[3][2],},
-		{t[0][1], t[1][1], t[2][1], t[3][1],},
-		{t[0][0], t[1][0], t[2][0], t[3][0],},
+	cells := tetrominoCells(t)
+	for i, cell := range cells {
+		cells[i] = [2]int{t.Size - 1 - cell[1], cell[0]}
 	}
+	return newTetrominoFromCells(t.Size, cells)
 }
 
 func (b *Board) applySmash() {
 	stepToGround := b.height
 
-	currentTetrominoBottomY := [4]int{0, 0, 0, 0}
-	for x := 0; x < 4; x++ {
-		for y := 0; y < 4; y++ {
-			if b.current[y][x] == 1 {
+	size := b.current.Size
+	currentTetrominoBottomY := make([]int, size, size)
+	for x := 0; x < size; x++ {
+		for y := 0; y < size; y++ {
+			if b.current.Block(y, x) {
 				currentTetrominoBottomY[x] = b.currentY + y
 			}
 		}
 	}
 
-	groundY := [4]int{b.height, b.height, b.height, b.height}
-	for x := 0; x < 4; x++ {
+	groundY := make([]int, size, size)
+	for x := 0; x < size; x++ {
 		if b.currentX+x >= b.width || b.currentX+x < 0 {
 			groundY[x] = -1
 			continue
 		}
 
+		groundY[x] = b.height
 		for y := currentTetrominoBottomY[x]; y < b.height; y++ {
 			if b.tiles[y][b.currentX+x] != TileEmpty {
 				groundY[x] = y
@@ -402,9 +1014,9 @@ func (b *Board) applySmash() {
 		}
 	}
 
-	for x := 0; x < 4; x++ {
-		for y := 3; y >= 0; y-- {
-			if b.current[y][x] == 1 {
+	for x := 0; x < size; x++ {
+		for y := size - 1; y >= 0; y-- {
+			if b.current.Block(y, x) {
 				stepToGround = minInt(stepToGround, groundY[x]-(b.currentY+y)-1)
 				break
 			}
@@ -412,9 +1024,10 @@ func (b *Board) applySmash() {
 	}
 
 	b.currentY += stepToGround
-	b.fillTilesWithCurrentTetromino()
-	b.popCompletedRows()
-	b.setupNextTetromino()
+	if stepToGround > 0 {
+		b.lastMoveRotation = false
+	}
+	b.lockCurrentTetromino()
 }
 
 func minInt(a, b int) int {
@@ -424,76 +1037,77 @@ func minInt(a, b int) int {
 	return b
 }
 
-func (b *Board) applyFill() {
-	if b.isTouchGround() {
-		b.fillTilesWithCurrentTetromino()
-		b.raiseGround()
-		b.popCompletedRows()
-		b.setupNextTetromino()
-		if b.isOverlapGround() {
-			b.isOver = true
-		}
-	} else {
-		b.raiseGround()
-	}
-}
-
-func (b *Board) raiseGround() {
-	for y := 0; y < b.height-1; y++ {
-		for x := 0; x < b.width; x++ {
-			b.tiles[y][x] = b.tiles[y+1][x]
-		}
-	}
-	for x := 0; x < b.width; x++ {
-		b.tiles[b.height-1][x] = TileAdditionalBlock
+func maxInt(a, b int) int {
+	if a > b {
+		return a
 	}
+	return b
 }
 
 func (b *Board) Render() [][]Tile {
 	b.m.RLock()
-	b.m.RUnlock()
+	defer b.m.RUnlock()
 
-	for y := 0; y < b.height; y++ {
+	for y := range b.renderFrame {
 		for x := 0; x < b.width; x++ {
-			b.renderFrame[y][x] = b.tiles[y][x]
+			b.renderFrame[y][x] = b.tiles[b.hiddenRows+y][x]
 		}
 	}
 
-	for y := 0; y < 4; y++ {
-		for x := 0; x < 4; x++ {
+	for y := 0; y < b.current.Size; y++ {
+		for x := 0; x < b.current.Size; x++ {
 			frameX := b.currentX + x
-			frameY := b.currentY + y
-			if b.current[y][x] == 1 && frameX >= 0 && frameX < b.width && frameY >= 0 && frameY < b.height {
+			frameY := b.currentY + y - b.hiddenRows
+			if b.current.Block(y, x) && frameX >= 0 && frameX < b.width && frameY >= 0 && frameY < len(b.renderFrame) {
 				b.renderFrame[frameY][frameX] = TileTetromino
 			}
 		}
 	}
 
-	return b.renderFrame
+	if len(b.transforms) > 0 {
+		context := b.renderContext()
+		for _, transform := range b.transforms {
+			transform.Transform(b.renderFrame, context)
+		}
+	}
+
+	return b.scaleFrame(b.renderFrame)
 }
 
 type RandomGetter struct {
+	seed       int64
+	drawn      int
 	randomizer *rand.Rand
 	tetrominos []Tetromino
 }
 
-func NewRandomGetter(seed int64) *RandomGetter {
+func NewRandomGetter(seed int64, tetrominos ...Tetromino) *RandomGetter {
 	return &RandomGetter{
+		seed:       seed,
+		drawn:      0,
 		randomizer: rand.New(rand.NewSource(seed)),
-		tetrominos: []Tetromino{
-			TetrominoT,
-			TetrominoL,
-			TetrominoZ,
-			TetrominoO,
-			TetrominoI,
-		},
+		tetrominos: tetrominoSet(tetrominos),
 	}
 }
 
 func (r *RandomGetter) Next() Tetromino {
+	r.drawn++
 	return r.tetrominos[r.randomizer.Int()%len(r.tetrominos)]
 }
 
+func (r *RandomGetter) GetState() GetterState {
+	return GetterState{Seed: r.seed, Drawn: r.drawn}
+}
+
+func (r *RandomGetter) SetState(state GetterState) {
+	r.seed = state.Seed
+	r.drawn = 0
+	r.randomizer = rand.New(rand.NewSource(state.Seed))
+	for r.drawn < state.Drawn {
+		r.Next()
+	}
+}
+
 type QueueTetrominoGetter struct {
 	queue []Tetromino
 }
@@ -503,6 +1117,9 @@ func NewQueueGetter() *QueueTetrominoGetter {
 }
 
 func (q *QueueTetrominoGetter) Next() Tetromino {
+	if len(q.queue) == 0 {
+		return Tetromino{}
+	}
 	t := q.queue[0]
 	q.queue = q.queue[1:]
 	return t
@@ -511,3 +1128,14 @@ func (q *QueueTetrominoGetter) Next() Tetromino {
 func (q *QueueTetrominoGetter) Push(t ...Tetromino) {
 	q.queue = append(q.queue, t...)
 }
+
+func (q *QueueTetrominoGetter) GetState() GetterState {
+	queue := make([]Tetromino, len(q.queue), len(q.queue))
+	copy(queue, q.queue)
+	return GetterState{Queue: queue}
+}
+
+func (q *QueueTetrominoGetter) SetState(state GetterState) {
+	q.queue = make([]Tetromino, len(state.Queue), len(state.Queue))
+	copy(q.queue, state.Queue)
+}